@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+
+	eth_crypto "github.com/celo-org/celo-blockchain/crypto"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/bootstrap"
+	"github.com/grassrootseconomics/cic-custodial/internal/keyarchive"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
+	"github.com/jackc/pgx/v5"
+)
+
+// encryptKeystore encrypts legacy plaintext private keys and webhook secrets in place with the current master key.
+// Private keys encrypted before they were bound to their public key are re-encrypted with it.
+// It is safe to run while the service is running and to re-run after an interruption.
+func encryptKeystore(ctx context.Context, _ []string) error {
+	keyProvider := bootstrap.KeyProvider(lo, ko)
+	if keyProvider == nil {
+		return errors.New("no master keys configured, set keystore.master_keys or keystore.master_key_file")
+	}
+
+	pgStore := bootstrap.PgStore(lo, ko, keyProvider, migrationsFolderFlag, queriesFlag)
+
+	encrypted, err := pgStore.EncryptKeystore(ctx)
+	if err != nil {
+		lo.Error("admin: keystore encryption interrupted", "encrypted", encrypted)
+		return err
+	}
+
+	lo.Info("admin: keystore encrypted", "encrypted", encrypted)
+
+	encryptedSecrets, err := pgStore.EncryptWebhookSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	lo.Info("admin: webhook secrets encrypted", "encrypted", encryptedSecrets)
+	return nil
+}
+
+// exportKeystore writes the selected accounts into a passphrase encrypted archive.
+// The archive carries the account activation and gas lock state so that the accounts can be used right after import.
+func exportKeystore(ctx context.Context, args []string) error {
+	var (
+		accountsFlag       string
+		accountsFileFlag   string
+		lightKDFFlag       bool
+		outFlag            string
+		passphraseFileFlag string
+	)
+
+	fs := flag.NewFlagSet("export-keystore", flag.ExitOnError)
+	fs.StringVar(&accountsFlag, "accounts", "", "Comma separated public keys to export")
+	fs.StringVar(&accountsFileFlag, "accounts-file", "", "File with a public key to export per line")
+	fs.BoolVar(&lightKDFFlag, "light-kdf", false, "Use the light scrypt parameters to speed up large exports")
+	fs.StringVar(&outFlag, "out", "keystore-archive.json", "Archive output location")
+	fs.StringVar(&passphraseFileFlag, "passphrase-file", "", "File containing the archive passphrase")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	publicKeys := strings.FieldsFunc(accountsFlag, func(r rune) bool {
+		return r == ','
+	})
+
+	if accountsFileFlag != "" {
+		accountsFileContent, err := os.ReadFile(accountsFileFlag)
+		if err != nil {
+			return err
+		}
+		publicKeys = append(publicKeys, strings.Fields(string(accountsFileContent))...)
+	}
+
+	if len(publicKeys) < 1 {
+		return errors.New("no accounts selected, set -accounts or -accounts-file")
+	}
+
+	passphrase, err := readPassphrase(passphraseFileFlag)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := bootstrap.PgStore(lo, ko, bootstrap.KeyProvider(lo, ko), migrationsFolderFlag, queriesFlag).GetKeystoreAccounts(ctx, publicKeys)
+	if err != nil {
+		return err
+	}
+
+	if len(accounts) < len(publicKeys) {
+		lo.Warn("admin: some selected accounts were not found", "selected", len(publicKeys), "found", len(accounts))
+	}
+
+	entries := make([]keyarchive.Entry, len(accounts))
+	for i, account := range accounts {
+		entries[i] = keyarchive.Entry{
+			PrivateKey: account.PrivateKey,
+			Active:     account.Active,
+			GasLocked:  account.GasLocked,
+		}
+	}
+
+	archive, err := keyarchive.Seal(entries, keyarchive.Opts{
+		LightKDF:   lightKDFFlag,
+		Passphrase: passphrase,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outFlag, archive, 0600); err != nil {
+		return err
+	}
+
+	lo.Info("admin: keystore exported", "accounts", len(entries), "out", outFlag)
+	return nil
+}
+
+// importKeystore saves every account in an archive which does not already exist and seeds its nonce from the network.
+func importKeystore(ctx context.Context, args []string) error {
+	var (
+		inFlag             string
+		passphraseFileFlag string
+	)
+
+	fs := flag.NewFlagSet("import-keystore", flag.ExitOnError)
+	fs.StringVar(&inFlag, "in", "keystore-archive.json", "Archive location")
+	fs.StringVar(&passphraseFileFlag, "passphrase-file", "", "File containing the archive passphrase")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(passphraseFileFlag)
+	if err != nil {
+		return err
+	}
+
+	archive, err := os.ReadFile(inFlag)
+	if err != nil {
+		return err
+	}
+
+	entries, err := keyarchive.Open(archive, passphrase)
+	if err != nil {
+		return err
+	}
+
+	pgStore := bootstrap.PgStore(lo, ko, bootstrap.KeyProvider(lo, ko), migrationsFolderFlag, queriesFlag)
+	celoProvider := bootstrap.CeloProvider(lo, ko)
+	noncestore := bootstrap.Noncestore(lo, ko, nil, celoProvider, pgStore)
+
+	var (
+		imported uint
+		skipped  uint
+	)
+
+	for _, entry := range entries {
+		var (
+			networkNonce uint64
+			publicKey    = eth_crypto.PubkeyToAddress(entry.PrivateKey.PublicKey).Hex()
+		)
+
+		// An existing account may be signing here already, its nonce must not be reset to the network nonce.
+		if _, _, err := pgStore.GetAccountStatus(ctx, publicKey); err == nil {
+			lo.Warn("admin: skipping duplicate account", "public_key", publicKey)
+			skipped++
+			continue
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		// The nonce is seeded before the insert, an interrupted import is then re-run without skipping an unseeded account.
+		if err := celoProvider.Client.CallCtx(
+			ctx,
+			eth.Nonce(celoutils.HexToAddress(publicKey), nil).Returns(&networkNonce),
+		); err != nil {
+			return err
+		}
+
+		if err := noncestore.SetAccountNonce(ctx, publicKey, networkNonce); err != nil {
+			return err
+		}
+
+		ok, err := pgStore.ImportKeystoreAccount(ctx, store.KeystoreAccount{
+			PublicKey:  publicKey,
+			PrivateKey: entry.PrivateKey,
+			Active:     entry.Active,
+			GasLocked:  entry.GasLocked,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			lo.Warn("admin: skipping duplicate account", "public_key", publicKey)
+			skipped++
+			continue
+		}
+
+		imported++
+	}
+
+	lo.Info("admin: keystore imported", "imported", imported, "skipped", skipped)
+	return nil
+}
+
+func readPassphrase(passphraseFile string) (string, error) {
+	if passphraseFile == "" {
+		return "", errors.New("no passphrase, set -passphrase-file")
+	}
+
+	passphrase, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(passphrase), "\r\n"), nil
+}
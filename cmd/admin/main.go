@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/bootstrap"
+	"github.com/knadh/koanf/v2"
+	"github.com/zerodha/logf"
+)
+
+type command struct {
+	description string
+	run         func(context.Context, []string) error
+}
+
+var (
+	build string
+
+	confFlag             string
+	debugFlag            bool
+	migrationsFolderFlag string
+	queriesFlag          string
+
+	lo logf.Logger
+	ko *koanf.Koanf
+
+	commands = map[string]command{
+		"backfill-approval-sender": {
+			description: "Record approvals signed before the sender fix under their authorizer",
+			run:         backfillApprovalSender,
+		},
+		"encrypt-keystore": {
+			description: "Encrypt plaintext or unbound private keys and plaintext webhook secrets in place",
+			run:         encryptKeystore,
+		},
+		"export-keystore": {
+			description: "Export selected accounts into a passphrase encrypted archive",
+			run:         exportKeystore,
+		},
+		"import-keystore": {
+			description: "Import accounts from an archive and seed their nonces from the network",
+			run:         importKeystore,
+		},
+		"set-webhook-secret": {
+			description: "Set the secret an API client's webhook deliveries are signed with",
+			run:         setWebhookSecret,
+		},
+	}
+)
+
+func init() {
+	flag.StringVar(&confFlag, "config", "config.toml", "Config file location")
+	flag.BoolVar(&debugFlag, "debug", false, "Enable debug logging")
+	flag.StringVar(&migrationsFolderFlag, "migrations", "migrations/", "Migrations folder location")
+	flag.StringVar(&queriesFlag, "queries", "queries.sql", "Queries file location")
+	flag.Usage = usage
+	flag.Parse()
+
+	lo = bootstrap.Logger(debugFlag)
+	// The admin commands share the service config.
+	ko = bootstrap.Config(lo, confFlag)
+}
+
+func main() {
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	lo.Info("admin: running command", "command", flag.Arg(0), "build", build)
+
+	if err := cmd.run(context.Background(), flag.Args()[1:]); err != nil {
+		lo.Fatal("admin: command failed", "command", flag.Arg(0), "error", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <command> [command flags]\n\nCommands:\n", os.Args[0])
+	for name, cmd := range commands {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-26s %s\n", name, cmd.description)
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\nFlags:\n")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/grassrootseconomics/cic-custodial/internal/bootstrap"
+)
+
+const backfillBatchSize = 500
+
+// backfillApprovalSender corrects approval otx's recorded under the system key to the authorizer which signed them.
+// The sender is recovered from the signed tx, re-running it only touches rows that are still wrong.
+func backfillApprovalSender(ctx context.Context, _ []string) error {
+	var (
+		afterId   uint
+		corrected uint
+	)
+
+	signer := bootstrap.CeloProvider(lo, ko).Signer
+	pgStore := bootstrap.PgStore(lo, ko, bootstrap.KeyProvider(lo, ko), migrationsFolderFlag, queriesFlag)
+
+	for {
+		otxSenders, err := pgStore.GetApprovalOtx(ctx, afterId, backfillBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, otx := range otxSenders {
+			afterId = otx.Id
+
+			rawTx, err := hexutil.Decode(otx.RawTx)
+			if err != nil {
+				return err
+			}
+
+			var tx types.Transaction
+			if err := tx.UnmarshalBinary(rawTx); err != nil {
+				return err
+			}
+
+			sender, err := types.Sender(signer, &tx)
+			if err != nil {
+				return err
+			}
+
+			if sender.Hex() == otx.From {
+				continue
+			}
+
+			if err := pgStore.SetOtxSender(ctx, otx.Id, sender.Hex()); err != nil {
+				lo.Error("admin: approval sender backfill interrupted", "corrected", corrected)
+				return err
+			}
+			corrected++
+		}
+
+		if len(otxSenders) < backfillBatchSize {
+			break
+		}
+	}
+
+	lo.Info("admin: approval senders backfilled", "corrected", corrected)
+	return nil
+}
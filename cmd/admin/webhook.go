@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/bootstrap"
+)
+
+const webhookSecretSize = 32
+
+// setWebhookSecret sets the secret an API client's webhook deliveries are signed with.
+// The secret is read from a file or generated and printed once, it is encrypted at rest if master keys are configured.
+func setWebhookSecret(ctx context.Context, args []string) error {
+	var (
+		clientFlag     string
+		secretFileFlag string
+	)
+
+	fs := flag.NewFlagSet("set-webhook-secret", flag.ExitOnError)
+	fs.StringVar(&clientFlag, "client", "", "API client name")
+	fs.StringVar(&secretFileFlag, "secret-file", "", "File containing the secret, a random secret is generated if not set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if clientFlag == "" {
+		return errors.New("no API client, set -client")
+	}
+
+	var (
+		secret    string
+		generated bool
+	)
+
+	if secretFileFlag != "" {
+		secretFileContent, err := os.ReadFile(secretFileFlag)
+		if err != nil {
+			return err
+		}
+		secret = strings.TrimRight(string(secretFileContent), "\r\n")
+	} else {
+		secretBytes := make([]byte, webhookSecretSize)
+		if _, err := rand.Read(secretBytes); err != nil {
+			return err
+		}
+		secret = hex.EncodeToString(secretBytes)
+		generated = true
+	}
+
+	if secret == "" {
+		return errors.New("empty webhook secret")
+	}
+
+	keyProvider := bootstrap.KeyProvider(lo, ko)
+	if keyProvider == nil {
+		lo.Warn("admin: no master keys configured, the webhook secret will be stored as plaintext")
+	}
+
+	ok, err := bootstrap.PgStore(lo, ko, keyProvider, migrationsFolderFlag, queriesFlag).SetWebhookSecret(ctx, clientFlag, secret)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("API client %s not found", clientFlag)
+	}
+
+	if generated {
+		fmt.Println(secret)
+	}
+
+	lo.Info("admin: webhook secret set", "client", clientFlag)
+	return nil
+}
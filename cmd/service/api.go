@@ -2,6 +2,7 @@ package main
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/VictoriaMetrics/metrics"
 	"github.com/go-playground/validator/v10"
@@ -14,8 +15,12 @@ import (
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
-const (
-	systemGlobalLockKey = "system:global_lock"
+var (
+	systemLockMessages = map[string]string{
+		custodial.LockGlobal:       "System manually locked.",
+		custodial.LockRegistration: "Account registration paused.",
+		custodial.LockSign:         "Signing paused.",
+	}
 )
 
 // Bootstrap API server.
@@ -32,7 +37,13 @@ func initApiServer(custodialContainer *custodial.Custodial) *echo.Echo {
 
 	server.Use(middleware.Recover())
 	server.Use(middleware.BodyLimit("1M"))
-	server.Use(middleware.ContextTimeout(util.SLATimeout))
+	server.Use(middleware.ContextTimeoutWithConfig(middleware.ContextTimeoutConfig{
+		// Track streams are long lived and bound by their own timeout.
+		Skipper: func(c echo.Context) bool {
+			return strings.HasSuffix(c.Path(), "/stream")
+		},
+		Timeout: util.SLATimeout,
+	}))
 
 	if ko.Bool("service.metrics") {
 		server.GET("/metrics", func(c echo.Context) error {
@@ -45,13 +56,27 @@ func initApiServer(custodialContainer *custodial.Custodial) *echo.Echo {
 		server.GET("/docs/*", echoSwagger.WrapHandler)
 	}
 
-	apiRoute := server.Group("/api", systemGlobalLock(custodialContainer))
-
-	apiRoute.POST("/account/create", api.HandleAccountCreate(custodialContainer))
-	apiRoute.GET("/account/status/:address", api.HandleNetworkAccountStatus(custodialContainer))
-	apiRoute.POST("/sign/transfer", api.HandleSignTransfer(custodialContainer))
-	apiRoute.POST("/sign/transferAuth", api.HandleSignTranserAuthorization(custodialContainer))
-	apiRoute.GET("/track/:trackingId", api.HandleTrackTx(custodialContainer))
+	// Admin routes are registered outside the /api group and are not subject to the system locks they manage.
+	adminRoute := server.Group("/api/admin", apiKeyAuth(custodialContainer))
+	adminRoute.GET("/locks", api.HandleAdminGetLocks(custodialContainer), requireScope(api.ScopeAdminLock))
+	adminRoute.POST("/lock", api.HandleAdminLock(custodialContainer), requireScope(api.ScopeAdminLock))
+	adminRoute.POST("/unlock", api.HandleAdminUnlock(custodialContainer), requireScope(api.ScopeAdminLock))
+	adminRoute.GET("/vouchers", api.HandleAdminGetVouchers(custodialContainer), requireScope(api.ScopeAdminVoucher))
+	adminRoute.POST("/vouchers", api.HandleAdminAddVoucher(custodialContainer), requireScope(api.ScopeAdminVoucher))
+	adminRoute.POST("/vouchers/sync", api.HandleAdminSyncVouchers(custodialContainer), requireScope(api.ScopeAdminVoucher))
+	adminRoute.DELETE("/vouchers/:voucherAddress", api.HandleAdminRemoveVoucher(custodialContainer), requireScope(api.ScopeAdminVoucher))
+
+	apiRoute := server.Group("/api", apiKeyAuth(custodialContainer), systemLock(custodialContainer, custodial.LockGlobal))
+
+	apiRoute.POST("/account/create", api.HandleAccountCreate(custodialContainer), requireScope(api.ScopeAccountCreate), systemLock(custodialContainer, custodial.LockRegistration), idempotency(custodialContainer))
+	apiRoute.GET("/account/status/:address", api.HandleNetworkAccountStatus(custodialContainer), requireScope(api.ScopeAccountRead))
+	apiRoute.GET("/account/:address/transactions", api.HandleAccountTransactions(custodialContainer), requireScope(api.ScopeAccountRead))
+	apiRoute.POST("/sign/transfer", api.HandleSignTransfer(custodialContainer), requireScope(api.ScopeSignTransfer), systemLock(custodialContainer, custodial.LockSign), idempotency(custodialContainer), rateLimit(custodialContainer, "sign_transfer"))
+	apiRoute.POST("/sign/transfer/batch", api.HandleSignTransferBatch(custodialContainer), requireScope(api.ScopeSignTransfer), systemLock(custodialContainer, custodial.LockSign), idempotency(custodialContainer), rateLimit(custodialContainer, "sign_transfer_batch"))
+	apiRoute.POST("/sign/transferAuth", api.HandleSignTranserAuthorization(custodialContainer), requireScope(api.ScopeSignTransferAuth), systemLock(custodialContainer, custodial.LockSign), idempotency(custodialContainer), rateLimit(custodialContainer, "sign_transfer_auth"))
+	apiRoute.GET("/track/:trackingId", api.HandleTrackTx(custodialContainer), requireScope(api.ScopeTrackRead))
+	apiRoute.GET("/track/:trackingId/stream", api.HandleTrackStream(custodialContainer), requireScope(api.ScopeTrackRead))
+	apiRoute.GET("/track/batch/:batchId", api.HandleTrackBatch(custodialContainer), requireScope(api.ScopeTrackRead))
 
 	return server
 }
@@ -84,10 +109,11 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 	})
 }
 
-func systemGlobalLock(cu *custodial.Custodial) echo.MiddlewareFunc {
+// systemLock rejects the request while the system lock scope is engaged.
+func systemLock(cu *custodial.Custodial, scope string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			locked, err := cu.RedisClient.Get(c.Request().Context(), systemGlobalLockKey).Bool()
+			locked, err := cu.IsLocked(c.Request().Context(), scope)
 			if err != nil {
 				return err
 			}
@@ -95,7 +121,7 @@ func systemGlobalLock(cu *custodial.Custodial) echo.MiddlewareFunc {
 			if locked {
 				return c.JSON(http.StatusServiceUnavailable, api.ErrResp{
 					Ok:      false,
-					Message: "System manually locked.",
+					Message: systemLockMessages[scope],
 				})
 			}
 
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/api"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	apiKeyHeader = "X-Api-Key"
+)
+
+// apiKeyAuth authenticates the request against the api_client table and sets the client on the echo context.
+func apiKeyAuth(cu *custodial.Custodial) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			apiKey := c.Request().Header.Get(apiKeyHeader)
+			if apiKey == "" {
+				return c.JSON(http.StatusUnauthorized, api.ErrResp{
+					Ok:      false,
+					Message: "Missing API key.",
+				})
+			}
+
+			keyHash := sha256.Sum256([]byte(apiKey))
+
+			apiClient, err := cu.Store.GetApiClient(c.Request().Context(), hex.EncodeToString(keyHash[:]))
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return c.JSON(http.StatusUnauthorized, api.ErrResp{
+						Ok:      false,
+						Message: "Invalid API key.",
+					})
+				}
+
+				return err
+			}
+
+			c.Set(api.ApiClientContextKey, apiClient)
+			return next(c)
+		}
+	}
+}
+
+// requireScope rejects the request if the authenticated API client was not granted the scope.
+func requireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !api.ApiClient(c).HasScope(scope) {
+				return c.JSON(http.StatusForbidden, api.ErrResp{
+					Ok:      false,
+					Message: "API key not authorized for this action.",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/api"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotencyReplayHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLen    = 255
+	idempotencyKeyPrefix    = "idempotency:"
+	// An in-flight request reserves the key for at most this duration in case the replica dies mid request.
+	idempotencyInFlightTTL = 1 * time.Minute
+	idempotencyResponseTTL = 24 * time.Hour
+	// Releasing or saving the key outlives the request context so that a client disconnect can't leave it unsaved.
+	idempotencySaveTimeout = 5 * time.Second
+)
+
+type (
+	// idempotentResponse is persisted in Redis so that any API replica can replay it.
+	// A zero Status indicates the first request is still in flight.
+	idempotentResponse struct {
+		RequestHash string          `json:"requestHash"`
+		Status      int             `json:"status"`
+		Body        json.RawMessage `json:"body,omitempty"`
+	}
+
+	bodyDumpResponseWriter struct {
+		io.Writer
+		http.ResponseWriter
+	}
+
+	// detachedContext keeps the values of its parent but is never cancelled with it.
+	detachedContext struct {
+		parent context.Context
+	}
+)
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
+
+func (w *bodyDumpResponseWriter) Write(b []byte) (int, error) {
+	return w.Writer.Write(b)
+}
+
+// idempotency persists the first successful response for an Idempotency-Key and replays it verbatim on repeat requests.
+// Keys are namespaced per API client. Reusing a key with a different request body is rejected with a conflict.
+func idempotency(cu *custodial.Custodial) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			idempotencyKey := c.Request().Header.Get(idempotencyKeyHeader)
+			if idempotencyKey == "" {
+				return next(c)
+			}
+
+			if len(idempotencyKey) > idempotencyKeyMaxLen {
+				return api.NewBadRequestError(fmt.Sprintf("Idempotency-Key exceeds %d characters.", idempotencyKeyMaxLen))
+			}
+
+			reqBody, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return err
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(reqBody))
+
+			reqHash := sha256.Sum256(append([]byte(c.Request().Method+c.Path()), canonicalBody(reqBody)...))
+			inFlight := idempotentResponse{
+				RequestHash: hex.EncodeToString(reqHash[:]),
+			}
+
+			inFlightValue, err := json.Marshal(inFlight)
+			if err != nil {
+				return err
+			}
+
+			redisKey := fmt.Sprintf("%s%d:%s", idempotencyKeyPrefix, api.ApiClient(c).Id, idempotencyKey)
+			ctx := c.Request().Context()
+
+			reserved, err := cu.RedisClient.SetNX(ctx, redisKey, inFlightValue, idempotencyInFlightTTL).Result()
+			if err != nil {
+				return err
+			}
+
+			if !reserved {
+				var (
+					saved idempotentResponse
+				)
+
+				savedValue, err := cu.RedisClient.Get(ctx, redisKey).Bytes()
+				if err != nil {
+					// The in-flight reservation expired between SetNX and Get.
+					if err == redis.Nil {
+						return idempotencyConflict(c, "Idempotency-Key is being processed. Try again later.")
+					}
+					return err
+				}
+
+				if err := json.Unmarshal(savedValue, &saved); err != nil {
+					return err
+				}
+
+				if saved.RequestHash != inFlight.RequestHash {
+					return idempotencyConflict(c, "Idempotency-Key already used with a different request.")
+				}
+
+				if saved.Status == 0 {
+					return idempotencyConflict(c, "Idempotency-Key is being processed. Try again later.")
+				}
+
+				c.Response().Header().Set(idempotencyReplayHeader, "true")
+				return c.JSONBlob(saved.Status, saved.Body)
+			}
+
+			resBody := new(bytes.Buffer)
+			c.Response().Writer = &bodyDumpResponseWriter{
+				Writer:         io.MultiWriter(c.Response().Writer, resBody),
+				ResponseWriter: c.Response().Writer,
+			}
+
+			saveCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, idempotencySaveTimeout)
+			defer cancel()
+
+			// Only successful responses are persisted, failures release the key so that the client can retry.
+			if err := next(c); err != nil || c.Response().Status >= http.StatusMultipleChoices {
+				if delErr := cu.RedisClient.Del(saveCtx, redisKey).Err(); delErr != nil {
+					lo.Error("api: failed to release idempotency key", "key", redisKey, "err", delErr)
+				}
+				return err
+			}
+
+			savedValue, err := json.Marshal(idempotentResponse{
+				RequestHash: inFlight.RequestHash,
+				Status:      c.Response().Status,
+				Body:        resBody.Bytes(),
+			})
+			if err != nil {
+				return err
+			}
+
+			// The response is already written, a retry after a failed save would be processed again.
+			if err := cu.RedisClient.Set(saveCtx, redisKey, savedValue, idempotencyResponseTTL).Err(); err != nil {
+				lo.Error("api: failed to persist idempotent response", "key", redisKey, "err", err)
+				return err
+			}
+
+			return nil
+		}
+	}
+}
+
+// canonicalBody re-encodes a JSON body with sorted keys and no insignificant whitespace so that equivalent requests hash the same.
+// A body that isn't valid JSON is hashed as is, the handler rejects it anyway.
+func canonicalBody(body []byte) []byte {
+	var v interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return body
+	}
+
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+
+	return canonical
+}
+
+func idempotencyConflict(c echo.Context, message string) error {
+	return c.JSON(http.StatusConflict, api.ErrResp{
+		Ok:      false,
+		Message: message,
+	})
+}
@@ -2,83 +2,22 @@ package main
 
 import (
 	"context"
-	"strings"
 
 	"github.com/bsm/redislock"
 	"github.com/grassrootseconomics/celoutils"
 	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
-	"github.com/grassrootseconomics/cic-custodial/internal/nonce"
+	"github.com/grassrootseconomics/cic-custodial/internal/feeoracle"
+	"github.com/grassrootseconomics/cic-custodial/internal/policy"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/signer"
 	"github.com/grassrootseconomics/cic-custodial/internal/store"
 	"github.com/grassrootseconomics/cic-custodial/internal/sub"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
-	"github.com/grassrootseconomics/cic-custodial/pkg/logg"
+	"github.com/grassrootseconomics/cic-custodial/internal/token"
 	"github.com/grassrootseconomics/cic-custodial/pkg/redis"
-	"github.com/knadh/koanf/parsers/toml"
-	"github.com/knadh/koanf/providers/env"
-	"github.com/knadh/koanf/providers/file"
-	"github.com/knadh/koanf/v2"
 	"github.com/nats-io/nats.go"
-	"github.com/zerodha/logf"
 )
 
-// Load logger.
-func initLogger() logf.Logger {
-	loggOpts := logg.LoggOpts{}
-
-	if debugFlag {
-		loggOpts.Color = true
-		loggOpts.Caller = true
-		loggOpts.Debug = true
-	}
-
-	return logg.NewLogg(loggOpts)
-}
-
-// Load config file.
-func initConfig() *koanf.Koanf {
-	var (
-		ko = koanf.New(".")
-	)
-
-	confFile := file.Provider(confFlag)
-	if err := ko.Load(confFile, toml.Parser()); err != nil {
-		lo.Fatal("init: could not load config file", "error", err)
-	}
-
-	if err := ko.Load(env.Provider("CUSTODIAL_", ".", func(s string) string {
-		return strings.ReplaceAll(strings.ToLower(
-			strings.TrimPrefix(s, "CUSTODIAL_")), "__", ".")
-	}), nil); err != nil {
-		lo.Fatal("init: could not override config from env vars", "error", err)
-	}
-
-	if debugFlag {
-		ko.Print()
-	}
-
-	return ko
-}
-
-// Load Celo chain provider.
-func initCeloProvider() *celoutils.Provider {
-	providerOpts := celoutils.ProviderOpts{
-		RpcEndpoint: ko.MustString("chain.rpc_endpoint"),
-	}
-
-	if ko.Bool("chain.testnet") {
-		providerOpts.ChainId = celoutils.TestnetChainId
-	} else {
-		providerOpts.ChainId = celoutils.MainnetChainId
-	}
-
-	provider, err := celoutils.NewProvider(providerOpts)
-	if err != nil {
-		lo.Fatal("init: critical error loading chain provider", "error", err)
-	}
-
-	return provider
-}
-
 // Load separate redis connection for the tasker on a reserved db namespace.
 func initAsynqRedisPool() *redis.RedisPool {
 	poolOpts := redis.RedisPoolOpts{
@@ -94,27 +33,93 @@ func initAsynqRedisPool() *redis.RedisPool {
 	return pool
 }
 
-// Common redis connection on a different db namespace from the takser.
-func initCommonRedisPool() *redis.RedisPool {
-	poolOpts := redis.RedisPoolOpts{
-		DSN:          ko.MustString("redis.dsn"),
-		MinIdleConns: ko.MustInt("redis.min_idle_conn"),
+// Load the configured signer backend.
+func initSigner(chainProvider *celoutils.Provider, store store.Store) signer.Signer {
+	switch backend := ko.MustString("signer.backend"); backend {
+	case "local":
+		localSigner, err := signer.NewLocalSigner(signer.LocalSignerOpts{
+			ChainSigner: chainProvider.Signer,
+			Store:       store,
+			SystemKeys:  initSystemKeys(),
+		})
+		if err != nil {
+			lo.Fatal("init: critical error loading local signer", "error", err)
+		}
+
+		return localSigner
+	case "memory":
+		lo.Warn("init: memory signer keys are lost on restart, only use it for offline development and testing")
+
+		memorySigner, err := signer.NewMemorySigner(signer.MemorySignerOpts{
+			ChainSigner: chainProvider.Signer,
+			SystemKeys:  initSystemKeys(),
+		})
+		if err != nil {
+			lo.Fatal("init: critical error loading memory signer", "error", err)
+		}
+
+		return memorySigner
+	case "remote":
+		return signer.NewRemoteSigner(signer.RemoteSignerOpts{
+			AuthToken:   ko.String("signer.auth_token"),
+			ChainSigner: chainProvider.Signer,
+			Endpoint:    ko.MustString("signer.endpoint"),
+			Timeout:     ko.MustDuration("signer.timeout"),
+		})
+	default:
+		lo.Fatal("init: unknown signer backend", "backend", backend)
+		return nil
 	}
+}
 
-	pool, err := redis.NewRedisPool(context.Background(), poolOpts)
-	if err != nil {
-		lo.Fatal("init: critical error connecting to common redis db", "error", err)
+// Load the system account and system pool private keys from config.
+func initSystemKeys() map[string]string {
+	systemKeys := map[string]string{
+		ko.MustString("system.public_key"): ko.MustString("system.private_key"),
 	}
 
-	return pool
+	poolPublicKeys := ko.Strings("system.pool_public_keys")
+	poolPrivateKeys := ko.Strings("system.pool_private_keys")
+	if len(poolPublicKeys) != len(poolPrivateKeys) {
+		lo.Fatal("init: system pool public and private keys do not match", "public_keys", len(poolPublicKeys), "private_keys", len(poolPrivateKeys))
+	}
+
+	for i, publicKey := range poolPublicKeys {
+		systemKeys[publicKey] = poolPrivateKeys[i]
+	}
+
+	return systemKeys
 }
 
-// Load redis backed noncestore.
-func initRedisNoncestore(redisPool *redis.RedisPool, chainProvider *celoutils.Provider, store store.Store) nonce.Noncestore {
-	return nonce.NewRedisNoncestore(nonce.Opts{
+// Load EIP-1559 fee oracle.
+func initFeeOracle(redisPool *redis.RedisPool, chainProvider *celoutils.Provider) *feeoracle.FeeOracle {
+	return feeoracle.NewFeeOracle(feeoracle.Opts{
+		BaseFeeMultiplier: ko.MustFloat64("fee_oracle.base_fee_multiplier"),
+		CacheTTL:          ko.MustDuration("fee_oracle.cache_ttl"),
+		ChainProvider:     chainProvider,
+		HistoryBlocks:     uint64(ko.MustInt64("fee_oracle.history_blocks")),
+		Logg:              lo,
+		MaxGasFeeCap:      ko.MustInt64("fee_oracle.max_gas_fee_cap"),
+		MinGasTipCap:      ko.MustInt64("fee_oracle.min_gas_tip_cap"),
+		RedisClient:       redisPool.Client,
+		TipMultiplier:     ko.MustFloat64("fee_oracle.tip_multiplier"),
+		TipPercentile:     ko.MustFloat64("fee_oracle.tip_percentile"),
+	})
+}
+
+// Load voucher metadata cache.
+func initTokenMetadata(redisPool *redis.RedisPool, chainProvider *celoutils.Provider) *token.MetadataCache {
+	return token.NewMetadataCache(token.Opts{
+		CacheTTL:      ko.MustDuration("token_metadata.cache_ttl"),
 		ChainProvider: chainProvider,
-		RedisPool:     redisPool,
-		Store:         store,
+		RedisClient:   redisPool.Client,
+	})
+}
+
+// Load transfer policy engine.
+func initPolicy(store store.Store) *policy.Policy {
+	return policy.NewPolicy(policy.Opts{
+		Store: store,
 	})
 }
 
@@ -130,20 +135,6 @@ func initTaskerClient(redisPool *redis.RedisPool) *tasker.TaskerClient {
 	})
 }
 
-// Load Postgres store.
-func initPgStore() store.Store {
-	store, err := store.NewPgStore(store.Opts{
-		DSN:                  ko.MustString("postgres.dsn"),
-		MigrationsFolderPath: migrationsFolderFlag,
-		QueriesFolderPath:    queriesFlag,
-	})
-	if err != nil {
-		lo.Fatal("init: critical error loading Postgres store", "error", err)
-	}
-
-	return store
-}
-
 // Init JetStream context for both pub/sub.
 func initJetStream() (*nats.Conn, nats.JetStreamContext) {
 	natsConn, err := nats.Connect(ko.MustString("jetstream.endpoint"))
@@ -160,6 +151,20 @@ func initJetStream() (*nats.Conn, nats.JetStreamContext) {
 	return natsConn, js
 }
 
+// Load JetStream publisher for outbound events.
+func initPub(jsCtx nats.JetStreamContext) *pub.Pub {
+	pub, err := pub.NewPub(pub.PubOpts{
+		DedupDuration:   ko.MustDuration("jetstream.dedup_duration"),
+		JsCtx:           jsCtx,
+		PersistDuration: ko.MustDuration("jetstream.persist_duration"),
+	})
+	if err != nil {
+		lo.Fatal("init: critical error bootstrapping pub", "error", err)
+	}
+
+	return pub
+}
+
 func initSub(natsConn *nats.Conn, jsCtx nats.JetStreamContext, cu *custodial.Custodial) *sub.Sub {
 	sub, err := sub.NewSub(sub.SubOpts{
 		CustodialContainer: cu,
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/grassrootseconomics/cic-custodial/internal/bootstrap"
 	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
 	"github.com/grassrootseconomics/cic-custodial/internal/sub"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
@@ -15,9 +16,10 @@ import (
 )
 
 type internalServicesContainer struct {
-	apiService    *echo.Echo
-	jetstreamSub  *sub.Sub
-	taskerService *tasker.TaskerServer
+	apiService      *echo.Echo
+	jetstreamSub    *sub.Sub
+	taskerScheduler *tasker.TaskerScheduler
+	taskerService   *tasker.TaskerServer
 }
 
 var (
@@ -39,36 +41,61 @@ func init() {
 	flag.StringVar(&queriesFlag, "queries", "queries.sql", "Queries file location")
 	flag.Parse()
 
-	lo = initLogger()
-	ko = initConfig()
+	lo = bootstrap.Logger(debugFlag)
+	ko = bootstrap.Config(lo, confFlag)
+
+	if debugFlag {
+		ko.Print()
+	}
 }
 
 func main() {
 	lo.Info("main: starting cic-custodial", "build", build)
 
-	celoProvider := initCeloProvider()
+	celoProvider := bootstrap.CeloProvider(lo, ko)
 	asynqRedisPool := initAsynqRedisPool()
-	redisPool := initCommonRedisPool()
+	redisPool := bootstrap.CommonRedisPool(lo, ko)
 
-	store := initPgStore()
-	redisNoncestore := initRedisNoncestore(redisPool, celoProvider, store)
+	keyProvider := bootstrap.KeyProvider(lo, ko)
+	store := bootstrap.PgStore(lo, ko, keyProvider, migrationsFolderFlag, queriesFlag)
+	noncestore := bootstrap.Noncestore(lo, ko, redisPool, celoProvider, store)
+	signer := initSigner(celoProvider, store)
 	lockProvider := initLockProvider(redisPool.Client)
+	feeOracle := initFeeOracle(redisPool, celoProvider)
 	taskerClient := initTaskerClient(asynqRedisPool)
 
 	natsConn, jsCtx := initJetStream()
+	pub := initPub(jsCtx)
 
 	custodial, err := custodial.NewCustodial(custodial.Opts{
-		ApprovalTimeout:  ko.MustDuration("system.approve_timeout"),
-		CeloProvider:     celoProvider,
-		LockProvider:     lockProvider,
-		Logg:             lo,
-		Noncestore:       redisNoncestore,
-		Store:            store,
-		RedisClient:      redisPool.Client,
-		RegistryAddress:  ko.MustString("chain.registry_address"),
-		SystemPrivateKey: ko.MustString("system.private_key"),
-		SystemPublicKey:  ko.MustString("system.public_key"),
-		TaskerClient:     taskerClient,
+		ApprovalTimeout: ko.MustDuration("system.approve_timeout"),
+		CeloProvider:    celoProvider,
+		FeeOracle:       feeOracle,
+		GasBumpPercent:  ko.MustInt64("replacement.gas_bump_percent"),
+		GasLimitMargin:  ko.MustInt64("chain.gas_limit_margin_percent"),
+		LockProvider:    lockProvider,
+		Logg:            lo,
+		MaxGasFeeCap:    ko.MustInt64("fee_oracle.max_gas_fee_cap"),
+		NonceRepair:     ko.Bool("reconcile.repair"),
+		Noncestore:      noncestore,
+		Policy:          initPolicy(store),
+		Pub:             pub,
+		Store:           store,
+		ReconcileWindow: ko.MustDuration("reconcile.active_window"),
+		RedisClient:     redisPool.Client,
+		RegistryAddress: ko.MustString("chain.registry_address"),
+		Signer:          signer,
+		StuckTxTimeout:  ko.MustDuration("replacement.stuck_after"),
+		SystemPool:      ko.Strings("system.pool_public_keys"),
+		SystemPublicKey: ko.MustString("system.public_key"),
+		TaskerClient:    taskerClient,
+		Thresholds: custodial.Thresholds{
+			WarnSystemBalance:  ko.Float64("balance_monitor.warn_system_balance"),
+			PauseSystemBalance: ko.Float64("balance_monitor.pause_system_balance"),
+			PauseFaucetBalance: ko.Float64("balance_monitor.pause_faucet_balance"),
+			LockSystemBalance:  ko.Float64("balance_monitor.lock_system_balance"),
+		},
+		TokenMetadata: initTokenMetadata(redisPool, celoProvider),
 	})
 	if err != nil {
 		lo.Fatal("main: crtical error loading custodial container", "error", err)
@@ -105,6 +132,12 @@ func main() {
 		}
 	}()
 
+	internalServices.taskerScheduler = initTaskerScheduler(asynqRedisPool)
+	lo.Info("main: starting tasker scheduler")
+	if err := internalServices.taskerScheduler.Start(); err != nil {
+		lo.Fatal("main: could not start task scheduler", "err", err)
+	}
+
 	internalServices.jetstreamSub = initSub(natsConn, jsCtx, custodial)
 	wg.Add(1)
 	go func() {
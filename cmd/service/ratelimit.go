@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/google/uuid"
+	"github.com/grassrootseconomics/cic-custodial/internal/api"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	rateLimitKeyPrefix = "ratelimit:"
+	retryAfterHeader   = "Retry-After"
+)
+
+// slidingWindowScript admits a request only if every window key is under its limit, the request is then recorded in all of them.
+// Each key is a sorted set of request ids scored by their time in ms.
+// KEYS: window keys
+// ARGV[1]: now (ms)
+// ARGV[2]: window (ms)
+// ARGV[3]: request id
+// ARGV[4..]: limit per key
+// Returns {0} if admitted or {retry after (ms), index of the rejecting key (1 based)}.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+for i, key in ipairs(KEYS) do
+	redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+	if redis.call("ZCARD", key) >= tonumber(ARGV[3 + i]) then
+		local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+		return {tonumber(oldest[2]) + window - now, i}
+	end
+end
+
+for _, key in ipairs(KEYS) do
+	redis.call("ZADD", key, now, ARGV[3])
+	redis.call("PEXPIRE", key, window)
+end
+
+return {0}
+`)
+
+type (
+	rateLimitWindow struct {
+		key   string
+		kind  string
+		limit int64
+	}
+
+	// rateLimitSenders picks the sender accounts out of the sign request bodies.
+	rateLimitSenders struct {
+		From       string `json:"from"`
+		Authorizer string `json:"authorizer"`
+		Transfers  []struct {
+			From string `json:"from"`
+		} `json:"transfers"`
+	}
+)
+
+// rateLimit applies a sliding window limit per sender account and per API client to a route.
+// Limits are read from the rate_limit.<route> config section, a zero limit is disabled.
+// A batch request counts once towards each of its distinct senders.
+// It runs after idempotency so that a replayed response is never counted or rate limited.
+func rateLimit(cu *custodial.Custodial, route string) echo.MiddlewareFunc {
+	var (
+		window     = ko.MustDuration("rate_limit." + route + ".window")
+		perAccount = ko.Int64("rate_limit." + route + ".per_account")
+		perClient  = ko.Int64("rate_limit." + route + ".per_client")
+	)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var (
+				windows []rateLimitWindow
+			)
+
+			if perClient > 0 {
+				windows = append(windows, rateLimitWindow{
+					key:   fmt.Sprintf("%s%s:client:%d", rateLimitKeyPrefix, route, api.ApiClient(c).Id),
+					kind:  "client",
+					limit: perClient,
+				})
+			}
+
+			if perAccount > 0 {
+				senders, err := readSenders(c)
+				if err != nil {
+					return err
+				}
+
+				for _, sender := range senders {
+					windows = append(windows, rateLimitWindow{
+						key:   rateLimitKeyPrefix + route + ":account:" + sender,
+						kind:  "account",
+						limit: perAccount,
+					})
+				}
+			}
+
+			if len(windows) < 1 {
+				return next(c)
+			}
+
+			var (
+				keys = make([]string, len(windows))
+				args = []interface{}{time.Now().UnixMilli(), window.Milliseconds(), uuid.NewString()}
+			)
+
+			for i, w := range windows {
+				keys[i] = w.key
+				args = append(args, w.limit)
+			}
+
+			result, err := slidingWindowScript.Run(c.Request().Context(), cu.RedisClient, keys, args...).Int64Slice()
+			if err != nil {
+				return err
+			}
+
+			if result[0] == 0 {
+				return next(c)
+			}
+
+			rejectedBy := windows[result[1]-1]
+			metrics.GetOrCreateCounter(fmt.Sprintf(`custodial_rate_limit_rejected_total{route=%q,limit=%q}`, route, rejectedBy.kind)).Inc()
+
+			retryAfter := (time.Duration(result[0])*time.Millisecond + time.Second - 1) / time.Second
+			c.Response().Header().Set(retryAfterHeader, strconv.FormatInt(int64(retryAfter), 10))
+
+			return c.JSON(http.StatusTooManyRequests, api.ErrResp{
+				Ok:      false,
+				Message: fmt.Sprintf("Rate limit exceeded for %s. Try again later.", rejectedBy.kind),
+			})
+		}
+	}
+}
+
+// readSenders returns the distinct sender accounts of the request body and restores the body for the handler.
+// An unparsable body has no senders, the handler rejects it.
+func readSenders(c echo.Context) ([]string, error) {
+	var (
+		body    rateLimitSenders
+		senders []string
+		seen    = make(map[string]bool)
+	)
+
+	reqBody, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request().Body = io.NopCloser(bytes.NewReader(reqBody))
+
+	if err := json.Unmarshal(reqBody, &body); err != nil {
+		return nil, nil
+	}
+
+	candidates := []string{body.From, body.Authorizer}
+	for _, transfer := range body.Transfers {
+		candidates = append(candidates, transfer.From)
+	}
+
+	for _, sender := range candidates {
+		if sender != "" && !seen[sender] {
+			seen[sender] = true
+			senders = append(senders, sender)
+		}
+	}
+
+	return senders, nil
+}
@@ -39,10 +39,60 @@ func initTasker(custodialContainer *custodial.Custodial, redisPool *redis.RedisP
 	taskerServer.RegisterHandlers(tasker.SignTransferTask, task.SignTransfer(custodialContainer))
 	taskerServer.RegisterHandlers(tasker.SignTransferTaskAuth, task.SignTransferAuthorizationProcessor(custodialContainer))
 	taskerServer.RegisterHandlers(tasker.DispatchTxTask, task.DispatchTx(custodialContainer))
+	taskerServer.RegisterHandlers(tasker.ScanStuckTxTask, task.ScanStuckTxProcessor(custodialContainer))
+	taskerServer.RegisterHandlers(tasker.ReplaceTxTask, task.ReplaceTxProcessor(custodialContainer))
+	taskerServer.RegisterHandlers(tasker.ReconcileNonceTask, task.ReconcileNonceProcessor(custodialContainer))
+	taskerServer.RegisterHandlers(tasker.SystemBalanceTask, task.SystemBalanceProcessor(custodialContainer))
+	taskerServer.RegisterHandlers(tasker.WebhookTask, task.WebhookProcessor(custodialContainer))
+	taskerServer.RegisterHandlers(tasker.SyncVouchersTask, task.SyncVouchersProcessor(custodialContainer))
+	taskerServer.RegisterHandlers(tasker.PublishEventTask, task.PublishEventProcessor(custodialContainer))
 
 	return taskerServer
 }
 
+// Load periodic tasks.
+func initTaskerScheduler(redisPool *redis.RedisPool) *tasker.TaskerScheduler {
+	taskerScheduler := tasker.NewTaskerScheduler(tasker.TaskerSchedulerOpts{
+		Logg:      lo,
+		LogLevel:  asynq.InfoLevel,
+		RedisPool: redisPool,
+	})
+
+	if err := taskerScheduler.RegisterPeriodicTask(
+		ko.MustDuration("replacement.scan_interval"),
+		tasker.ScanStuckTxTask,
+		tasker.DefaultPriority,
+	); err != nil {
+		lo.Fatal("init: critical error registering periodic task", "task", tasker.ScanStuckTxTask, "error", err)
+	}
+
+	if err := taskerScheduler.RegisterPeriodicTask(
+		ko.MustDuration("reconcile.scan_interval"),
+		tasker.ReconcileNonceTask,
+		tasker.DefaultPriority,
+	); err != nil {
+		lo.Fatal("init: critical error registering periodic task", "task", tasker.ReconcileNonceTask, "error", err)
+	}
+
+	if err := taskerScheduler.RegisterPeriodicTask(
+		ko.MustDuration("balance_monitor.interval"),
+		tasker.SystemBalanceTask,
+		tasker.DefaultPriority,
+	); err != nil {
+		lo.Fatal("init: critical error registering periodic task", "task", tasker.SystemBalanceTask, "error", err)
+	}
+
+	if err := taskerScheduler.RegisterPeriodicTask(
+		ko.MustDuration("voucher_allowlist.sync_interval"),
+		tasker.SyncVouchersTask,
+		tasker.DefaultPriority,
+	); err != nil {
+		lo.Fatal("init: critical error registering periodic task", "task", tasker.SyncVouchersTask, "error", err)
+	}
+
+	return taskerScheduler
+}
+
 func isFailureHandler(err error) bool {
 	switch err {
 	// Ignore lock contention errors; retry until lock obtain.
@@ -54,6 +104,11 @@ func isFailureHandler(err error) bool {
 }
 
 func retryHandler(count int, err error, task *asynq.Task) time.Duration {
+	// Callback endpoints and JetStream may be down for a while, back off from the first retry.
+	if task.Type() == string(tasker.WebhookTask) || task.Type() == string(tasker.PublishEventTask) {
+		return asynq.DefaultRetryDelayFunc(count, err, task)
+	}
+
 	if count < fixedRetryCount {
 		return fixedRetryPeriod
 	} else {
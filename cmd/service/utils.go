@@ -27,5 +27,6 @@ func startGracefulShutdown(ctx context.Context, internalServices *internalServic
 		lo.Fatal("Could not gracefully shutdown api server", "err", err)
 	}
 
+	internalServices.taskerScheduler.Stop()
 	internalServices.taskerService.Stop()
 }
@@ -27,7 +27,12 @@ const docTemplate = `{
     "paths": {
         "/account/create": {
             "post": {
-                "description": "Create a new custodial account.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new custodial account.\nIf callbackUrl is set, status changes of the registration are POSTed to it signed with the API client's webhook secret.",
                 "consumes": [
                     "*/*"
                 ],
@@ -38,6 +43,388 @@ const docTemplate = `{
                     "account"
                 ],
                 "summary": "Create a new custodial account.",
+                "parameters": [
+                    {
+                        "description": "Account Create Request",
+                        "name": "accountCreateRequest",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "callbackUrl": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key, the first response is replayed on repeat requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/account/status/{address}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return network balance and nonce.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "network"
+                ],
+                "summary": "Get an address's network balance and nonce.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Account Public Key",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/account/{address}/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return OTX's (Origin transactions) signed by a custodial account, newest first.\nPass the returned nextCursor as the cursor query param to fetch the next page.\nVoucher transfer values are returned as decimal strings in base units and formatted with the voucher decimals and symbol.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "account"
+                ],
+                "summary": "Get the transaction history of a custodial account.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Account Public Key",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "ACCOUNT_REGISTER",
+                            "REFILL_GAS",
+                            "TRANSFER_AUTHORIZATION",
+                            "TRANSFER_VOUCHER"
+                        ],
+                        "type": "string",
+                        "description": "OTX type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dispatch status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 created at lower bound (inclusive)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 created at upper bound (exclusive)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/lock": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lock a scope: global blocks every /api route, registration blocks account creation and sign blocks the /sign routes.\nThe lock is auto released after expiresIn seconds, 0 keeps it until unlocked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Engage a system lock.",
+                "parameters": [
+                    {
+                        "description": "Lock Request",
+                        "name": "lockRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "expiresIn": {
+                                    "type": "integer"
+                                },
+                                "reason": {
+                                    "type": "string"
+                                },
+                                "scope": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/locks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return every system lock scope with its state, time left before auto release and its last change.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the system lock state.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unlock a scope regardless of who engaged it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Release a system lock.",
+                "parameters": [
+                    {
+                        "description": "Unlock Request",
+                        "name": "unlockRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                },
+                                "scope": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -45,6 +432,185 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.OkResp"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vouchers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return the cached voucher allowlist, the manually allowlisted vouchers and the time of the last sync.\nSigning is only allowed against allowlisted vouchers.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the voucher allowlist.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Manually allowlist a voucher which is not in the token index, it is allowed immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Allowlist a voucher.",
+                "parameters": [
+                    {
+                        "description": "Add Voucher Request",
+                        "name": "addVoucherRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                },
+                                "voucherAddress": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vouchers/sync": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rebuild the voucher allowlist from the token index without waiting for the periodic sync.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Sync the voucher allowlist.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -54,9 +620,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/account/status/{address}": {
-            "get": {
-                "description": "Return network balance and nonce.",
+        "/admin/vouchers/{voucherAddress}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a manually allowlisted voucher and resync the allowlist.\nA voucher in the token index stays allowed until it is removed from the index.",
                 "consumes": [
                     "*/*"
                 ],
@@ -64,14 +635,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "network"
+                    "admin"
                 ],
-                "summary": "Get an address's network balance and nonce.",
+                "summary": "Remove a manually allowlisted voucher.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Account Public Key",
-                        "name": "address",
+                        "description": "Voucher address",
+                        "name": "voucherAddress",
                         "in": "path",
                         "required": true
                     }
@@ -83,8 +654,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.OkResp"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.ErrResp"
                         }
@@ -100,7 +683,12 @@ const docTemplate = `{
         },
         "/sign/transfer": {
             "post": {
-                "description": "Sign and dispatch a transfer request.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sign and dispatch a transfer request.\nSet either amount in base units (a uint256 as a JSON number or string) or decimalAmount (e.g. \"12.5\") which is converted exactly using the voucher decimals.\nIf callbackUrl is set, status changes are POSTed to it signed with the API client's webhook secret.\nThe voucher must be allowlisted.\nA transfer exceeding the active transfer policy of the sender is rejected with 403 and the violated policy.",
                 "consumes": [
                     "application/json"
                 ],
@@ -121,7 +709,13 @@ const docTemplate = `{
                             "type": "object",
                             "properties": {
                                 "amount": {
-                                    "type": "integer"
+                                    "type": "string"
+                                },
+                                "callbackUrl": {
+                                    "type": "string"
+                                },
+                                "decimalAmount": {
+                                    "type": "string"
                                 },
                                 "from": {
                                     "type": "string"
@@ -134,6 +728,112 @@ const docTemplate = `{
                                 }
                             }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key, the first response is replayed on repeat requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/sign/transfer/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Validate each transfer in the batch and queue the valid ones under a shared batch id.\nInvalid transfers are rejected individually with a reason, they do not fail the whole batch.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "network"
+                ],
+                "summary": "Sign and dispatch a batch of transfer requests.",
+                "parameters": [
+                    {
+                        "description": "Sign Transfer Batch Request",
+                        "name": "signTransferBatchRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "transfers": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object",
+                                        "properties": {
+                                            "amount": {
+                                                "type": "string"
+                                            },
+                                            "decimalAmount": {
+                                                "type": "string"
+                                            },
+                                            "from": {
+                                                "type": "string"
+                                            },
+                                            "to": {
+                                                "type": "string"
+                                            },
+                                            "voucherAddress": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key, the first response is replayed on repeat requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -149,6 +849,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -160,7 +878,12 @@ const docTemplate = `{
         },
         "/sign/transferAuth": {
             "post": {
-                "description": "Sign and dispatch a transfer authorization (approve) request.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sign and dispatch a transfer authorization (approve) request.\nSet either amount in base units (a uint256 as a JSON number or string) or decimalAmount (e.g. \"12.5\") which is converted exactly using the voucher decimals.\nIf callbackUrl is set, status changes are POSTed to it signed with the API client's webhook secret.\nThe voucher must be allowlisted.\nThe approved amount is checked against the authorizer's transfer policy (max per transfer and max per day) and counts toward the daily value.",
                 "consumes": [
                     "application/json"
                 ],
@@ -181,7 +904,7 @@ const docTemplate = `{
                             "type": "object",
                             "properties": {
                                 "amount": {
-                                    "type": "integer"
+                                    "type": "string"
                                 },
                                 "authorizedAddress": {
                                     "type": "string"
@@ -189,11 +912,90 @@ const docTemplate = `{
                                 "authorizer": {
                                     "type": "string"
                                 },
+                                "callbackUrl": {
+                                    "type": "string"
+                                },
+                                "decimalAmount": {
+                                    "type": "string"
+                                },
                                 "voucherAddress": {
                                     "type": "string"
                                 }
                             }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idempotency key, the first response is replayed on repeat requests",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/track/batch/{batchId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Track the status of every transfer in a batch along with an aggregate count per status.\nTransfer values are returned as decimal strings in base units and formatted with the voucher decimals and symbol.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "track"
+                ],
+                "summary": "Track the status of every transfer in a batch.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch Id",
+                        "name": "batchId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -209,6 +1011,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -220,7 +1040,12 @@ const docTemplate = `{
         },
         "/track/{trackingId}": {
             "get": {
-                "description": "Track an OTX (Origin transaction) status.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Track the status of every OTX (Origin transaction) signed under a tracking id in the order they were signed.\nRequests rejected before signing (e.g. a transfer that would revert) are listed under failures with the reason.\nWebhook delivery attempts are listed under webhookDeliveries.\nVoucher transfer values are returned as decimal strings in base units and formatted with the voucher decimals and symbol.\nDeprecated: the transactions are also returned under the former transaction key, clients should move to transactions before it is removed.",
                 "consumes": [
                     "*/*"
                 ],
@@ -253,6 +1078,91 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/track/{trackingId}/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Open a server-sent events stream. The first event (snapshot) carries the current failures and transactions as returned by /track/{trackingId}.\nThe snapshot is empty for a request which was accepted but not yet signed, the stream stays open for its status changes.\nEach later event is named after the status change (otx_signed, otx_dispatched, otx_dispatch_failed, otx_success, otx_reverted, otx_rejected).\nThe stream is closed once a terminal status is reached.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "track"
+                ],
+                "summary": "Stream the OTX (Origin transaction) status changes of a tracking id.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tracking Id",
+                        "name": "trackingId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -290,6 +1200,13 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-Api-Key",
+            "in": "header"
+        }
     }
 }`
 
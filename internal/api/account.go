@@ -3,27 +3,54 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker/task"
-	"github.com/grassrootseconomics/cic-custodial/pkg/keypair"
 	"github.com/labstack/echo/v4"
 )
 
 // HandleAccountCreate godoc
 //	@Summary		Create a new custodial account.
 //	@Description	Create a new custodial account.
+//	@Description	If callbackUrl is set, status changes of the registration are POSTed to it signed with the API client's webhook secret.
 //	@Tags			account
 //	@Accept			*/*
 //	@Produce		json
-//	@Success		200	{object}	OkResp
-//	@Failure		500	{object}	ErrResp
+//	@Param			accountCreateRequest	body		object{callbackUrl=string}	false	"Account Create Request"
+//	@Param			Idempotency-Key			header		string						false	"Idempotency key, the first response is replayed on repeat requests"
+//	@Success		200						{object}	OkResp
+//	@Failure		400						{object}	ErrResp
+//	@Failure		401						{object}	ErrResp
+//	@Failure		403						{object}	ErrResp
+//	@Failure		409						{object}	ErrResp
+//	@Failure		500						{object}	ErrResp
+//	@Security		ApiKeyAuth
 //	@Router			/account/create [post]
 func HandleAccountCreate(cu *custodial.Custodial) func(echo.Context) error {
 	return func(c echo.Context) error {
-		generatedKeyPair, err := keypair.Generate()
+		var (
+			req struct {
+				CallbackUrl string `json:"callbackUrl" validate:"omitempty,http_url"`
+			}
+		)
+
+		if err := c.Bind(&req); err != nil {
+			return NewBadRequestError(ErrInvalidJSON)
+		}
+
+		if err := c.Validate(req); err != nil {
+			return err
+		}
+
+		if err := checkCallbackUrl(c, req.CallbackUrl); err != nil {
+			return err
+		}
+
+		generatedKeyPair, err := cu.Signer.NewAccount(c.Request().Context())
 		if err != nil {
 			return err
 		}
@@ -34,7 +61,13 @@ func HandleAccountCreate(cu *custodial.Custodial) func(echo.Context) error {
 		}
 
 		trackingId := uuid.NewString()
+
+		if err := registerWebhook(c, cu, trackingId, req.CallbackUrl); err != nil {
+			return err
+		}
+
 		taskPayload, err := json.Marshal(task.AccountPayload{
+			ClientId:   ApiClient(c).Id,
 			PublicKey:  generatedKeyPair.Public,
 			TrackingId: trackingId,
 		})
@@ -55,6 +88,8 @@ func HandleAccountCreate(cu *custodial.Custodial) func(echo.Context) error {
 			return err
 		}
 
+		markTrackAccepted(c, cu, trackingId)
+
 		return c.JSON(http.StatusOK, OkResp{
 			Ok: true,
 			Result: H{
@@ -65,3 +100,94 @@ func HandleAccountCreate(cu *custodial.Custodial) func(echo.Context) error {
 		})
 	}
 }
+
+// HandleAccountTransactions godoc
+//
+//	@Summary		Get the transaction history of a custodial account.
+//	@Description	Return OTX's (Origin transactions) signed by a custodial account, newest first.
+//	@Description	Pass the returned nextCursor as the cursor query param to fetch the next page.
+//	@Description	Voucher transfer values are returned as decimal strings in base units and formatted with the voucher decimals and symbol.
+//	@Tags			account
+//	@Accept			*/*
+//	@Produce		json
+//	@Param			address	path		string	true	"Account Public Key"
+//	@Param			type	query		string	false	"OTX type"	Enums(ACCOUNT_REGISTER, REFILL_GAS, TRANSFER_AUTHORIZATION, TRANSFER_VOUCHER)
+//	@Param			status	query		string	false	"Dispatch status"
+//	@Param			since	query		string	false	"RFC3339 created at lower bound (inclusive)"
+//	@Param			until	query		string	false	"RFC3339 created at upper bound (exclusive)"
+//	@Param			cursor	query		int		false	"Cursor"
+//	@Param			limit	query		int		false	"Page size (max 100)"
+//	@Success		200		{object}	OkResp
+//	@Failure		400		{object}	ErrResp
+//	@Failure		401		{object}	ErrResp
+//	@Failure		403		{object}	ErrResp
+//	@Failure		500		{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/account/{address}/transactions [get]
+func HandleAccountTransactions(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		var (
+			req struct {
+				Address string `param:"address" validate:"required,eth_addr_checksum"`
+				Type    string `query:"type" validate:"omitempty,oneof=ACCOUNT_REGISTER REFILL_GAS TRANSFER_AUTHORIZATION TRANSFER_VOUCHER"`
+				Status  string `query:"status" validate:"omitempty,oneof=SIGNED IN_NETWORK OBSOLETE SUCCESS FAIL_NO_GAS FAIL_LOW_NONCE FAIL_LOW_GAS_PRICE FAIL_UNKNOWN_RPC_ERROR REVERTED"`
+				Since   string `query:"since" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+				Until   string `query:"until" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+				Cursor  uint   `query:"cursor"`
+				Limit   uint   `query:"limit" validate:"omitempty,max=100"`
+			}
+		)
+
+		if err := c.Bind(&req); err != nil {
+			return NewBadRequestError(err)
+		}
+
+		if err := c.Validate(req); err != nil {
+			return err
+		}
+
+		filter := store.TxHistoryFilter{
+			From:   req.Address,
+			Type:   req.Type,
+			Status: req.Status,
+			Cursor: req.Cursor,
+			Limit:  req.Limit,
+		}
+
+		if filter.Limit == 0 {
+			filter.Limit = defaultPageSize
+		}
+
+		if req.Since != "" {
+			since, _ := time.Parse(time.RFC3339, req.Since)
+			since = since.UTC()
+			filter.Since = &since
+		}
+
+		if req.Until != "" {
+			until, _ := time.Parse(time.RFC3339, req.Until)
+			until = until.UTC()
+			filter.Until = &until
+		}
+
+		txs, err := cu.Store.GetAccountTransactions(c.Request().Context(), filter)
+		if err != nil {
+			return err
+		}
+
+		newAmountFormatter(c.Request().Context(), cu).formatTxs(txs)
+
+		result := H{
+			"transactions": txs,
+		}
+
+		if len(txs) == int(filter.Limit) {
+			result["nextCursor"] = txs[len(txs)-1].Id
+		}
+
+		return c.JSON(http.StatusOK, OkResp{
+			Ok:     true,
+			Result: result,
+		})
+	}
+}
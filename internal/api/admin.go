@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/labstack/echo/v4"
+)
+
+type lockRequest struct {
+	Scope     string `json:"scope" validate:"required,oneof=global registration sign"`
+	Reason    string `json:"reason" validate:"required"`
+	ExpiresIn uint   `json:"expiresIn"`
+}
+
+// HandleAdminGetLocks godoc
+//
+//	@Summary		Get the system lock state.
+//	@Description	Return every system lock scope with its state, time left before auto release and its last change.
+//	@Tags			admin
+//	@Accept			*/*
+//	@Produce		json
+//	@Success		200	{object}	OkResp
+//	@Failure		401	{object}	ErrResp
+//	@Failure		403	{object}	ErrResp
+//	@Failure		500	{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/admin/locks [get]
+func HandleAdminGetLocks(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		lockAudits, err := cu.Store.GetLatestLockAudits(c.Request().Context())
+		if err != nil {
+			return err
+		}
+
+		locks := make(H, len(custodial.LockKeys))
+		for scope := range custodial.LockKeys {
+			locked, err := cu.IsLocked(c.Request().Context(), scope)
+			if err != nil {
+				return err
+			}
+
+			ttl, err := cu.LockTTL(c.Request().Context(), scope)
+			if err != nil {
+				return err
+			}
+
+			lock := H{
+				"locked":    locked,
+				"expiresIn": uint(ttl.Seconds()),
+			}
+
+			for _, lockAudit := range lockAudits {
+				if lockAudit.Scope == scope {
+					lock["lastChange"] = lockAudit
+				}
+			}
+
+			locks[scope] = lock
+		}
+
+		return c.JSON(http.StatusOK, OkResp{
+			Ok: true,
+			Result: H{
+				"locks": locks,
+			},
+		})
+	}
+}
+
+// HandleAdminLock godoc
+//
+//	@Summary		Engage a system lock.
+//	@Description	Lock a scope: global blocks every /api route, registration blocks account creation and sign blocks the /sign routes.
+//	@Description	The lock is auto released after expiresIn seconds, 0 keeps it until unlocked.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			lockRequest	body		object{scope=string,reason=string,expiresIn=uint}	true	"Lock Request"
+//	@Success		200			{object}	OkResp
+//	@Failure		400			{object}	ErrResp
+//	@Failure		401			{object}	ErrResp
+//	@Failure		403			{object}	ErrResp
+//	@Failure		500			{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/admin/lock [post]
+func HandleAdminLock(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		return setLock(c, cu, true)
+	}
+}
+
+// HandleAdminUnlock godoc
+//
+//	@Summary		Release a system lock.
+//	@Description	Unlock a scope regardless of who engaged it.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			unlockRequest	body		object{scope=string,reason=string}	true	"Unlock Request"
+//	@Success		200				{object}	OkResp
+//	@Failure		400				{object}	ErrResp
+//	@Failure		401				{object}	ErrResp
+//	@Failure		403				{object}	ErrResp
+//	@Failure		500				{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/admin/unlock [post]
+func HandleAdminUnlock(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		return setLock(c, cu, false)
+	}
+}
+
+func setLock(c echo.Context, cu *custodial.Custodial, locked bool) error {
+	var (
+		req lockRequest
+	)
+
+	if err := c.Bind(&req); err != nil {
+		return NewBadRequestError(ErrInvalidJSON)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if !locked && req.ExpiresIn > 0 {
+		return NewBadRequestError("expiresIn only applies to lock.")
+	}
+
+	changed, err := cu.SetLock(c.Request().Context(), custodial.LockChange{
+		Scope:  req.Scope,
+		Locked: locked,
+		Reason: req.Reason,
+		SetBy:  ApiClient(c).Name,
+		TTL:    time.Duration(req.ExpiresIn) * time.Second,
+		Manual: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, OkResp{
+		Ok: true,
+		Result: H{
+			"scope":   req.Scope,
+			"locked":  locked,
+			"changed": changed,
+		},
+	})
+}
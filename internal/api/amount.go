@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/cic-custodial/internal/token"
+)
+
+// maxAmountBits is the bit length of the largest ERC20 (uint256) amount.
+const maxAmountBits = 256
+
+// parseAmount parses an amount in base units, it is accepted as a JSON number or a string
+// so that clients which cannot represent large integers exactly can send it as a string.
+func parseAmount(amount json.Number) (*big.Int, error) {
+	baseUnits, ok := new(big.Int).SetString(amount.String(), 10)
+	if !ok || baseUnits.Sign() < 0 || baseUnits.BitLen() > maxAmountBits {
+		return nil, NewBadRequestError("Invalid amount: must be a uint256 integer in base units.")
+	}
+
+	return baseUnits, nil
+}
+
+// parseDecimalAmount converts a decimal amount to base units using the voucher decimals.
+// An amount that cannot be converted exactly is returned as a bad request error.
+func parseDecimalAmount(metadata token.Metadata, decimalAmount string) (*big.Int, error) {
+	baseUnits, err := metadata.ParseAmount(decimalAmount)
+	if err != nil {
+		return nil, NewBadRequestError(fmt.Sprintf("Invalid decimalAmount: %v.", err))
+	}
+
+	if baseUnits.BitLen() > maxAmountBits {
+		return nil, NewBadRequestError("Invalid decimalAmount: amount exceeds uint256.")
+	}
+
+	return baseUnits, nil
+}
+
+// amountFormatter formats transfer values of a response, voucher metadata is looked up once per voucher.
+// Formatting is best effort, a value is left unformatted if its voucher metadata is unavailable.
+type amountFormatter struct {
+	ctx      context.Context
+	cu       *custodial.Custodial
+	metadata map[string]*token.Metadata
+}
+
+func newAmountFormatter(ctx context.Context, cu *custodial.Custodial) *amountFormatter {
+	return &amountFormatter{
+		ctx:      ctx,
+		cu:       cu,
+		metadata: make(map[string]*token.Metadata),
+	}
+}
+
+// format returns the decimal value and voucher symbol, both are empty if the value cannot be formatted.
+func (f *amountFormatter) format(voucherAddress *string, value string) (string, string) {
+	if voucherAddress == nil {
+		return "", ""
+	}
+
+	baseUnits, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return "", ""
+	}
+
+	metadata, ok := f.metadata[*voucherAddress]
+	if !ok {
+		fetchedMetadata, err := f.cu.TokenMetadata.Metadata(f.ctx, *voucherAddress)
+		if err != nil {
+			f.cu.Logg.Warn("api: could not load voucher metadata", "voucher", *voucherAddress, "error", err)
+		} else {
+			metadata = &fetchedMetadata
+		}
+		f.metadata[*voucherAddress] = metadata
+	}
+
+	if metadata == nil {
+		return "", ""
+	}
+
+	return metadata.FormatAmount(baseUnits), metadata.Symbol
+}
+
+func (f *amountFormatter) formatTxs(txs []store.TxStatus) {
+	for i := range txs {
+		txs[i].FormattedValue, txs[i].Symbol = f.format(txs[i].VoucherAddress, txs[i].TransferValue)
+	}
+}
+
+func (f *amountFormatter) formatBatchTxs(txs []store.BatchTxStatus) {
+	for i := range txs {
+		if txs[i].TransferValue != nil {
+			txs[i].FormattedValue, txs[i].Symbol = f.format(txs[i].VoucherAddress, *txs[i].TransferValue)
+		}
+	}
+}
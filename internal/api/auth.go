@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// ApiClientContextKey is the echo context key under which the authenticated API client is set.
+	ApiClientContextKey = "apiClient"
+
+	ScopeAccountCreate    = "account:create"
+	ScopeAdminLock        = "admin:lock"
+	ScopeAdminVoucher     = "admin:voucher"
+	ScopeAccountRead      = "account:read"
+	ScopeSignTransfer     = "sign:transfer"
+	ScopeSignTransferAuth = "sign:transferAuth"
+	ScopeTrackRead        = "track:read"
+)
+
+// ApiClient returns the authenticated API client for the current request.
+func ApiClient(c echo.Context) store.ApiClient {
+	apiClient, _ := c.Get(ApiClientContextKey).(store.ApiClient)
+	return apiClient
+}
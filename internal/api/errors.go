@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -9,3 +10,16 @@ import (
 func NewBadRequestError(message ...interface{}) *echo.HTTPError {
 	return echo.NewHTTPError(http.StatusBadRequest, message...)
 }
+
+func NewNotFoundError(message ...interface{}) *echo.HTTPError {
+	return echo.NewHTTPError(http.StatusNotFound, message...)
+}
+
+// errorMessage extracts the client facing message from an error.
+func errorMessage(err error) string {
+	if he, ok := err.(*echo.HTTPError); ok {
+		return fmt.Sprint(he.Message)
+	}
+
+	return err.Error()
+}
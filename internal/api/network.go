@@ -21,7 +21,10 @@ import (
 //	@Param			address	path		string	true	"Account Public Key"
 //	@Success		200		{object}	OkResp
 //	@Failure		400		{object}	ErrResp
+//	@Failure		401		{object}	ErrResp
+//	@Failure		403		{object}	ErrResp
 //	@Failure		500		{object}	ErrResp
+//	@Security		ApiKeyAuth
 //	@Router			/account/status/{address} [get]
 func HandleNetworkAccountStatus(cu *custodial.Custodial) func(echo.Context) error {
 	return func(c echo.Context) error {
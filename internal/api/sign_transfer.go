@@ -1,7 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"math/big"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -9,29 +12,49 @@ import (
 
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker/task"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 )
 
+type transferRequest struct {
+	From           string `json:"from" validate:"required,eth_addr_checksum"`
+	To             string `json:"to" validate:"required,eth_addr_checksum"`
+	VoucherAddress string `json:"voucherAddress" validate:"required,eth_addr_checksum"`
+	// Amount is in base units, DecimalAmount (e.g. "12.5") is converted using the voucher decimals.
+	Amount        json.Number `json:"amount" validate:"required_without=DecimalAmount,excluded_with=DecimalAmount"`
+	DecimalAmount string      `json:"decimalAmount" validate:"required_without=Amount,excluded_with=Amount"`
+
+	// baseUnits is the amount resolved by checkTransfer.
+	baseUnits *big.Int
+}
+
 // HandleSignTransfer godoc
 //
 //	@Summary		Sign and dispatch transfer request.
 //	@Description	Sign and dispatch a transfer request.
+//	@Description	Set either amount in base units (a uint256 as a JSON number or string) or decimalAmount (e.g. "12.5") which is converted exactly using the voucher decimals.
+//	@Description	If callbackUrl is set, status changes are POSTed to it signed with the API client's webhook secret.
+//	@Description	The voucher must be allowlisted.
+//	@Description	A transfer exceeding the active transfer policy of the sender is rejected with 403 and the violated policy.
 //	@Tags			network
 //	@Accept			json
 //	@Produce		json
-//	@Param			signTransferRequest	body		object{from=string,to=string,voucherAddress=string,amount=uint64}	true	"Sign Transfer Request"
+//	@Param			signTransferRequest	body		object{from=string,to=string,voucherAddress=string,amount=string,decimalAmount=string,callbackUrl=string}	true	"Sign Transfer Request"
+//	@Param			Idempotency-Key		header		string																				false	"Idempotency key, the first response is replayed on repeat requests"
 //	@Success		200					{object}	OkResp
 //	@Failure		400					{object}	ErrResp
+//	@Failure		401					{object}	ErrResp
+//	@Failure		403					{object}	ErrResp
+//	@Failure		409					{object}	ErrResp
 //	@Failure		500					{object}	ErrResp
+//	@Security		ApiKeyAuth
 //	@Router			/sign/transfer [post]
 func HandleSignTransfer(cu *custodial.Custodial) func(echo.Context) error {
 	return func(c echo.Context) error {
 		var (
 			req struct {
-				From           string `json:"from" validate:"required,eth_addr_checksum"`
-				To             string `json:"to" validate:"required,eth_addr_checksum"`
-				VoucherAddress string `json:"voucherAddress" validate:"required,eth_addr_checksum"`
-				Amount         uint64 `json:"amount" validate:"gt=0"`
+				transferRequest
+				CallbackUrl string `json:"callbackUrl" validate:"omitempty,http_url"`
 			}
 		)
 
@@ -43,33 +66,35 @@ func HandleSignTransfer(cu *custodial.Custodial) func(echo.Context) error {
 			return err
 		}
 
-		accountActive, gasLock, err := cu.Store.GetAccountStatus(c.Request().Context(), req.From)
-		if err != nil {
+		if err := checkCallbackUrl(c, req.CallbackUrl); err != nil {
 			return err
 		}
 
-		if !accountActive {
-			return c.JSON(http.StatusForbidden, ErrResp{
-				Ok:      false,
-				Message: "Account pending activation. Try again later.",
-			})
+		rejectReason, err := checkTransfer(c.Request().Context(), cu, &req.transferRequest)
+		if err != nil {
+			return err
 		}
 
-		if gasLock {
+		if rejectReason != "" {
 			return c.JSON(http.StatusForbidden, ErrResp{
 				Ok:      false,
-				Message: "Gas lock. Gas balance unavailable. Try again later.",
+				Message: rejectReason,
 			})
 		}
 
 		trackingId := uuid.NewString()
 
+		if err := registerWebhook(c, cu, trackingId, req.CallbackUrl); err != nil {
+			return err
+		}
+
 		taskPayload, err := json.Marshal(task.TransferPayload{
+			ClientId:       ApiClient(c).Id,
 			TrackingId:     trackingId,
 			From:           req.From,
 			To:             req.To,
 			VoucherAddress: req.VoucherAddress,
-			Amount:         req.Amount,
+			Amount:         req.baseUnits,
 		})
 		if err != nil {
 			return err
@@ -88,6 +113,8 @@ func HandleSignTransfer(cu *custodial.Custodial) func(echo.Context) error {
 			return err
 		}
 
+		markTrackAccepted(c, cu, trackingId)
+
 		return c.JSON(http.StatusOK, OkResp{
 			Ok: true,
 			Result: H{
@@ -96,3 +123,65 @@ func HandleSignTransfer(cu *custodial.Custodial) func(echo.Context) error {
 		})
 	}
 }
+
+// checkTransfer returns a non-empty reason if the transfer is rejected before it is queued.
+// The amount is resolved to base units, an invalid amount is returned as a bad request error.
+func checkTransfer(ctx context.Context, cu *custodial.Custodial, req *transferRequest) (string, error) {
+	voucherAllowed, err := cu.IsVoucherAllowed(ctx, req.VoucherAddress)
+	if err != nil {
+		return "", err
+	}
+
+	if !voucherAllowed {
+		return voucherNotAllowedMessage, nil
+	}
+
+	if req.DecimalAmount != "" {
+		metadata, err := cu.TokenMetadata.Metadata(ctx, req.VoucherAddress)
+		if err != nil {
+			return "", err
+		}
+
+		req.baseUnits, err = parseDecimalAmount(metadata, req.DecimalAmount)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		req.baseUnits, err = parseAmount(req.Amount)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if req.baseUnits.Sign() == 0 {
+		return "", NewBadRequestError("Invalid amount: must be greater than 0.")
+	}
+
+	rejectReason, err := checkAccountStatus(ctx, cu, req.From)
+	if err != nil || rejectReason != "" {
+		return rejectReason, err
+	}
+
+	return cu.Policy.CheckTransfer(ctx, req.From, req.VoucherAddress, req.baseUnits)
+}
+
+// checkAccountStatus returns a non-empty reject reason if the account cannot currently sign transactions.
+func checkAccountStatus(ctx context.Context, cu *custodial.Custodial, publicKey string) (string, error) {
+	accountActive, gasLock, err := cu.Store.GetAccountStatus(ctx, publicKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "Account not found.", nil
+		}
+		return "", err
+	}
+
+	if !accountActive {
+		return "Account pending activation. Try again later.", nil
+	}
+
+	if gasLock {
+		return "Gas lock. Gas balance unavailable. Try again later.", nil
+	}
+
+	return "", nil
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"math/big"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -11,29 +12,40 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-// Max 10k vouchers per approval session
-const approvalSafetyLimit = 10000 * 1000000
+// Max 10k vouchers per approval session, scaled to base units with the voucher decimals
+const approvalSafetyLimit = 10000
 
 // HandleSignTransferAuthorization godoc
 //
 //	@Summary		Sign and dispatch a transfer authorization (approve) request.
 //	@Description	Sign and dispatch a transfer authorization (approve) request.
+//	@Description	Set either amount in base units (a uint256 as a JSON number or string) or decimalAmount (e.g. "12.5") which is converted exactly using the voucher decimals.
+//	@Description	If callbackUrl is set, status changes are POSTed to it signed with the API client's webhook secret.
+//	@Description	The voucher must be allowlisted.
+//	@Description	The approved amount is checked against the authorizer's transfer policy (max per transfer and max per day) and counts toward the daily value.
 //	@Tags			network
 //	@Accept			json
 //	@Produce		json
-//	@Param			signTransferAuthorzationRequest	body		object{amount=uint64,authorizer=string,authorizedAddress=string,voucherAddress=string}	true	"Sign Transfer Authorization (approve) Request"
+//	@Param			signTransferAuthorzationRequest	body		object{amount=string,decimalAmount=string,authorizer=string,authorizedAddress=string,voucherAddress=string,callbackUrl=string}	true	"Sign Transfer Authorization (approve) Request"
+//	@Param			Idempotency-Key					header		string																										false	"Idempotency key, the first response is replayed on repeat requests"
 //	@Success		200								{object}	OkResp
 //	@Failure		400								{object}	ErrResp
+//	@Failure		401								{object}	ErrResp
+//	@Failure		403								{object}	ErrResp
+//	@Failure		409								{object}	ErrResp
 //	@Failure		500								{object}	ErrResp
+//	@Security		ApiKeyAuth
 //	@Router			/sign/transferAuth [post]
 func HandleSignTranserAuthorization(cu *custodial.Custodial) func(echo.Context) error {
 	return func(c echo.Context) error {
 		var (
 			req struct {
-				Amount            uint64 `json:"amount" validate:"gte=0"`
-				Authorizer        string `json:"authorizer" validate:"required,eth_addr_checksum"`
-				AuthorizedAddress string `json:"authorizedAddress" validate:"required,eth_addr_checksum"`
-				VoucherAddress    string `json:"voucherAddress" validate:"required,eth_addr_checksum"`
+				Amount            json.Number `json:"amount" validate:"excluded_with=DecimalAmount"`
+				DecimalAmount     string      `json:"decimalAmount"`
+				Authorizer        string      `json:"authorizer" validate:"required,eth_addr_checksum"`
+				AuthorizedAddress string      `json:"authorizedAddress" validate:"required,eth_addr_checksum"`
+				VoucherAddress    string      `json:"voucherAddress" validate:"required,eth_addr_checksum"`
+				CallbackUrl       string      `json:"callbackUrl" validate:"omitempty,http_url"`
 			}
 		)
 
@@ -45,18 +57,65 @@ func HandleSignTranserAuthorization(cu *custodial.Custodial) func(echo.Context)
 			return err
 		}
 
-		accountActive, gasLock, err := cu.Store.GetAccountStatus(c.Request().Context(), req.Authorizer)
+		if err := checkCallbackUrl(c, req.CallbackUrl); err != nil {
+			return err
+		}
+
+		voucherAllowed, err := cu.IsVoucherAllowed(c.Request().Context(), req.VoucherAddress)
 		if err != nil {
 			return err
 		}
 
-		if req.Amount > approvalSafetyLimit {
+		// An allowance granted before the voucher was removed from the allowlist can still be revoked.
+		if !voucherAllowed && !isRevokeRequest(string(req.Amount), req.DecimalAmount) {
 			return c.JSON(http.StatusForbidden, ErrResp{
 				Ok:      false,
-				Message: "Approval amount per session exceeds 10k.",
+				Message: voucherNotAllowedMessage,
 			})
 		}
 
+		// An approval without an amount revokes the allowance.
+		amount := new(big.Int)
+		if req.DecimalAmount != "" {
+			metadata, err := cu.TokenMetadata.Metadata(c.Request().Context(), req.VoucherAddress)
+			if err != nil {
+				return err
+			}
+
+			amount, err = parseDecimalAmount(metadata, req.DecimalAmount)
+			if err != nil {
+				return err
+			}
+		} else if req.Amount != "" {
+			amount, err = parseAmount(req.Amount)
+			if err != nil {
+				return err
+			}
+		}
+
+		accountActive, gasLock, err := cu.Store.GetAccountStatus(c.Request().Context(), req.Authorizer)
+		if err != nil {
+			return err
+		}
+
+		// The voucher decimals are only needed if the amount could exceed the limit, i.e. it is above the limit in base units.
+		if amount.Cmp(big.NewInt(approvalSafetyLimit)) > 0 {
+			metadata, err := cu.TokenMetadata.Metadata(c.Request().Context(), req.VoucherAddress)
+			if err != nil {
+				return err
+			}
+
+			approvalLimit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(metadata.Decimals)), nil)
+			approvalLimit.Mul(approvalLimit, big.NewInt(approvalSafetyLimit))
+
+			if amount.Cmp(approvalLimit) > 0 {
+				return c.JSON(http.StatusForbidden, ErrResp{
+					Ok:      false,
+					Message: "Approval amount per session exceeds 10k.",
+				})
+			}
+		}
+
 		if !accountActive {
 			return c.JSON(http.StatusForbidden, ErrResp{
 				Ok:      false,
@@ -71,13 +130,30 @@ func HandleSignTranserAuthorization(cu *custodial.Custodial) func(echo.Context)
 			})
 		}
 
+		policyViolation, err := cu.Policy.CheckApproval(c.Request().Context(), req.Authorizer, req.VoucherAddress, amount)
+		if err != nil {
+			return err
+		}
+
+		if policyViolation != "" {
+			return c.JSON(http.StatusForbidden, ErrResp{
+				Ok:      false,
+				Message: policyViolation,
+			})
+		}
+
 		trackingId := uuid.NewString()
 
+		if err := registerWebhook(c, cu, trackingId, req.CallbackUrl); err != nil {
+			return err
+		}
+
 		taskPayload, err := json.Marshal(task.TransferAuthPayload{
 			TrackingId:        trackingId,
-			Amount:            req.Amount,
+			Amount:            amount,
 			Authorizer:        req.Authorizer,
 			AuthorizedAddress: req.AuthorizedAddress,
+			ClientId:          ApiClient(c).Id,
 			VoucherAddress:    req.VoucherAddress,
 		})
 		if err != nil {
@@ -97,6 +173,8 @@ func HandleSignTranserAuthorization(cu *custodial.Custodial) func(echo.Context)
 			return err
 		}
 
+		markTrackAccepted(c, cu, trackingId)
+
 		return c.JSON(http.StatusOK, OkResp{
 			Ok: true,
 			Result: H{
@@ -105,3 +183,17 @@ func HandleSignTranserAuthorization(cu *custodial.Custodial) func(echo.Context)
 		})
 	}
 }
+
+// isRevokeRequest checks whether an approval request sets the allowance to zero, before the amount is parsed and validated.
+func isRevokeRequest(amount string, decimalAmount string) bool {
+	if decimalAmount != "" {
+		amount = decimalAmount
+	}
+
+	if amount == "" {
+		return true
+	}
+
+	parsedAmount, ok := new(big.Rat).SetString(amount)
+	return ok && parsedAmount.Sign() == 0
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
+	"github.com/grassrootseconomics/cic-custodial/internal/tasker/task"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxBatchTransfers = 500
+	// batchQueueTimeout bounds queueing a full batch independently of the request timeout.
+	batchQueueTimeout = 1 * time.Minute
+)
+
+type batchTransferResult struct {
+	Index      int    `json:"index"`
+	Ok         bool   `json:"ok"`
+	TrackingId string `json:"trackingId,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// HandleSignTransferBatch godoc
+//
+//	@Summary		Sign and dispatch a batch of transfer requests.
+//	@Description	Validate each transfer in the batch and queue the valid ones under a shared batch id.
+//	@Description	Invalid transfers are rejected individually with a reason, they do not fail the whole batch.
+//	@Tags			network
+//	@Accept			json
+//	@Produce		json
+//	@Param			signTransferBatchRequest	body		object{transfers=[]object{from=string,to=string,voucherAddress=string,amount=string,decimalAmount=string}}	true	"Sign Transfer Batch Request"
+//	@Param			Idempotency-Key				header		string																					false	"Idempotency key, the first response is replayed on repeat requests"
+//	@Success		200							{object}	OkResp
+//	@Failure		400							{object}	ErrResp
+//	@Failure		401							{object}	ErrResp
+//	@Failure		403							{object}	ErrResp
+//	@Failure		409							{object}	ErrResp
+//	@Failure		500							{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/sign/transfer/batch [post]
+func HandleSignTransferBatch(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		var (
+			req struct {
+				Transfers []transferRequest `json:"transfers"`
+			}
+		)
+
+		if err := c.Bind(&req); err != nil {
+			return NewBadRequestError(ErrInvalidJSON)
+		}
+
+		if len(req.Transfers) == 0 || len(req.Transfers) > maxBatchTransfers {
+			return NewBadRequestError(fmt.Sprintf("Batch must contain between 1 and %d transfers.", maxBatchTransfers))
+		}
+
+		var (
+			batchId     = uuid.NewString()
+			clientId    = ApiClient(c).Id
+			results     = make([]batchTransferResult, len(req.Transfers))
+			trackingIds []string
+		)
+
+		for i := range req.Transfers {
+			results[i].Index = i
+
+			if err := c.Validate(req.Transfers[i]); err != nil {
+				results[i].Message = errorMessage(err)
+				continue
+			}
+
+			// Transfers of the same sender within the batch are not counted against each other's transfer policy until they are signed.
+			rejectReason, err := checkTransfer(c.Request().Context(), cu, &req.Transfers[i])
+			if err != nil {
+				if _, ok := err.(*echo.HTTPError); ok {
+					results[i].Message = errorMessage(err)
+					continue
+				}
+				return err
+			}
+
+			if rejectReason != "" {
+				results[i].Message = rejectReason
+				continue
+			}
+
+			results[i].Ok = true
+			results[i].TrackingId = uuid.NewString()
+			trackingIds = append(trackingIds, results[i].TrackingId)
+		}
+
+		if len(trackingIds) > 0 {
+			if err := cu.Store.CreateBatch(c.Request().Context(), batchId, trackingIds, clientId); err != nil {
+				return err
+			}
+		}
+
+		var (
+			failedTrackingIds []string
+			queuedTrackingIds []string
+		)
+
+		// Queueing up to the max batch size can outlast the request timeout or the client.
+		// Stopping midway would fail a request whose transfers are partly queued, a retry would then queue them again.
+		queueCtx, cancel := context.WithTimeout(context.Background(), batchQueueTimeout)
+		defer cancel()
+
+		for i, transfer := range req.Transfers {
+			if !results[i].Ok {
+				continue
+			}
+
+			taskPayload, err := json.Marshal(task.TransferPayload{
+				ClientId:       clientId,
+				TrackingId:     results[i].TrackingId,
+				From:           transfer.From,
+				To:             transfer.To,
+				VoucherAddress: transfer.VoucherAddress,
+				Amount:         transfer.baseUnits,
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = cu.TaskerClient.CreateTask(
+				queueCtx,
+				tasker.SignTransferTask,
+				tasker.HighPriority,
+				&tasker.Task{
+					Id:      results[i].TrackingId,
+					Payload: taskPayload,
+				},
+			)
+			if err != nil {
+				cu.Logg.Error("api: failed to queue batch transfer", "batch_id", batchId, "tracking_id", results[i].TrackingId, "error", err)
+				failedTrackingIds = append(failedTrackingIds, results[i].TrackingId)
+				results[i] = batchTransferResult{
+					Index:   i,
+					Message: "Failed to queue transfer. Try again later.",
+				}
+				continue
+			}
+
+			queuedTrackingIds = append(queuedTrackingIds, results[i].TrackingId)
+		}
+
+		if len(queuedTrackingIds) > 0 {
+			if err := cu.MarkTrackAccepted(queueCtx, queuedTrackingIds...); err != nil {
+				cu.Logg.Error("track: failed to mark tracking ids accepted", "tracking_ids", queuedTrackingIds, "error", err)
+			}
+		}
+
+		// The per item results are returned regardless, the failed items are only left dangling in the batch.
+		if len(failedTrackingIds) > 0 {
+			if err := cu.Store.DeleteBatchItems(queueCtx, batchId, failedTrackingIds); err != nil {
+				cu.Logg.Error("api: failed to remove unqueued batch items", "batch_id", batchId, "tracking_ids", failedTrackingIds, "error", err)
+			}
+		}
+
+		result := H{
+			"transfers": results,
+		}
+
+		if len(trackingIds) > len(failedTrackingIds) {
+			result["batchId"] = batchId
+		}
+
+		return c.JSON(http.StatusOK, OkResp{
+			Ok:     true,
+			Result: result,
+		})
+	}
+}
@@ -13,3 +13,7 @@ package api
 //	@license.url	https://www.gnu.org/licenses/agpl-3.0.en.html
 
 //	@BasePath	/api
+
+//	@securityDefinitions.apikey	ApiKeyAuth
+//	@in							header
+//	@name						X-Api-Key
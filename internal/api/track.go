@@ -1,22 +1,42 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	trackStreamTimeout   = 10 * time.Minute
+	trackStreamHeartbeat = 15 * time.Second
+)
+
 // HandleTrackTx godoc
 //	@Summary		Track an OTX (Origin transaction) status.
-//	@Description	Track an OTX (Origin transaction) status.
+//	@Description	Track the status of every OTX (Origin transaction) signed under a tracking id in the order they were signed.
+//	@Description	Requests rejected before signing (e.g. a transfer that would revert) are listed under failures with the reason.
+//	@Description	Webhook delivery attempts are listed under webhookDeliveries.
+//	@Description	Voucher transfer values are returned as decimal strings in base units and formatted with the voucher decimals and symbol.
+//	@Description	Deprecated: the transactions are also returned under the former transaction key, clients should move to transactions before it is removed.
 //	@Tags			track
 //	@Accept			*/*
 //	@Produce		json
 //	@Param			trackingId	path		string	true	"Tracking Id"
 //	@Success		200			{object}	OkResp
 //	@Failure		400			{object}	ErrResp
+//	@Failure		401			{object}	ErrResp
+//	@Failure		403			{object}	ErrResp
+//	@Failure		404			{object}	ErrResp
 //	@Failure		500			{object}	ErrResp
+//	@Security		ApiKeyAuth
 //	@Router			/track/{trackingId} [get]
 func HandleTrackTx(cu *custodial.Custodial) func(echo.Context) error {
 	return func(c echo.Context) error {
@@ -39,11 +59,259 @@ func HandleTrackTx(cu *custodial.Custodial) func(echo.Context) error {
 			return err
 		}
 
+		failures, err := cu.Store.GetSignFailures(c.Request().Context(), txStatusRequest.TrackingId)
+		if err != nil {
+			return err
+		}
+
+		if len(txs) < 1 && len(failures) < 1 {
+			return NewNotFoundError("Tracking id not found.")
+		}
+
+		webhookDeliveries, err := cu.Store.GetWebhookDeliveries(c.Request().Context(), txStatusRequest.TrackingId)
+		if err != nil {
+			return err
+		}
+
+		newAmountFormatter(c.Request().Context(), cu).formatTxs(txs)
+
 		return c.JSON(http.StatusOK, OkResp{
 			Ok: true,
 			Result: H{
+				"failures":          failures,
+				"transactions":      txs,
+				"webhookDeliveries": webhookDeliveries,
+				// Deprecated: kept for clients of the former single key response.
 				"transaction": txs,
 			},
 		})
 	}
 }
+
+// HandleTrackBatch godoc
+//
+//	@Summary		Track the status of every transfer in a batch.
+//	@Description	Track the status of every transfer in a batch along with an aggregate count per status.
+//	@Description	Transfer values are returned as decimal strings in base units and formatted with the voucher decimals and symbol.
+//	@Tags			track
+//	@Accept			*/*
+//	@Produce		json
+//	@Param			batchId	path		string	true	"Batch Id"
+//	@Success		200		{object}	OkResp
+//	@Failure		400		{object}	ErrResp
+//	@Failure		401		{object}	ErrResp
+//	@Failure		403		{object}	ErrResp
+//	@Failure		404		{object}	ErrResp
+//	@Failure		500		{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/track/batch/{batchId} [get]
+func HandleTrackBatch(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		var (
+			batchStatusRequest struct {
+				BatchId string `param:"batchId" validate:"required,uuid"`
+			}
+		)
+
+		if err := c.Bind(&batchStatusRequest); err != nil {
+			return NewBadRequestError(err)
+		}
+
+		if err := c.Validate(batchStatusRequest); err != nil {
+			return err
+		}
+
+		txs, err := cu.Store.GetBatchStatus(c.Request().Context(), batchStatusRequest.BatchId)
+		if err != nil {
+			return err
+		}
+
+		if len(txs) < 1 {
+			return NewNotFoundError("Batch not found.")
+		}
+
+		summary := make(map[string]int)
+		for _, tx := range txs {
+			summary[tx.Status]++
+		}
+
+		newAmountFormatter(c.Request().Context(), cu).formatBatchTxs(txs)
+
+		return c.JSON(http.StatusOK, OkResp{
+			Ok: true,
+			Result: H{
+				"summary":      summary,
+				"transactions": txs,
+			},
+		})
+	}
+}
+
+// HandleTrackStream godoc
+//
+//	@Summary		Stream the OTX (Origin transaction) status changes of a tracking id.
+//	@Description	Open a server-sent events stream. The first event (snapshot) carries the current failures and transactions as returned by /track/{trackingId}.
+//	@Description	The snapshot is empty for a request which was accepted but not yet signed, the stream stays open for its status changes.
+//	@Description	Each later event is named after the status change (otx_signed, otx_dispatched, otx_dispatch_failed, otx_success, otx_reverted, otx_rejected).
+//	@Description	The stream is closed once a terminal status is reached.
+//	@Tags			track
+//	@Accept			*/*
+//	@Produce		text/event-stream
+//	@Param			trackingId	path		string	true	"Tracking Id"
+//	@Success		200			{string}	string
+//	@Failure		400			{object}	ErrResp
+//	@Failure		401			{object}	ErrResp
+//	@Failure		403			{object}	ErrResp
+//	@Failure		404			{object}	ErrResp
+//	@Failure		500			{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/track/{trackingId}/stream [get]
+func HandleTrackStream(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		var (
+			txStatusRequest struct {
+				TrackingId string `param:"trackingId" validate:"required,uuid"`
+			}
+		)
+
+		if err := c.Bind(&txStatusRequest); err != nil {
+			return NewBadRequestError(err)
+		}
+
+		if err := c.Validate(txStatusRequest); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), trackStreamTimeout)
+		defer cancel()
+
+		// Subscribe before reading the current state so that a status change in between is not lost.
+		trackCh, unsubscribe, err := cu.SubscribeTrack(ctx, txStatusRequest.TrackingId)
+		if err != nil {
+			return err
+		}
+		defer unsubscribe()
+
+		txs, err := cu.Store.GetTxStatus(ctx, txStatusRequest.TrackingId)
+		if err != nil {
+			return err
+		}
+
+		failures, err := cu.Store.GetSignFailures(ctx, txStatusRequest.TrackingId)
+		if err != nil {
+			return err
+		}
+
+		// Signing is asynchronous, a request accepted moments ago has neither an otx nor a failure yet.
+		if len(txs) < 1 && len(failures) < 1 {
+			accepted, err := cu.IsTrackAccepted(ctx, txStatusRequest.TrackingId)
+			if err != nil {
+				return err
+			}
+
+			if !accepted {
+				return NewNotFoundError("Tracking id not found.")
+			}
+
+			txs, failures = []store.TxStatus{}, []store.SignFailure{}
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+		c.Response().Header().Set(echo.HeaderConnection, "keep-alive")
+		c.Response().WriteHeader(http.StatusOK)
+
+		newAmountFormatter(ctx, cu).formatTxs(txs)
+
+		snapshot, err := json.Marshal(H{
+			"failures":     failures,
+			"transactions": txs,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := writeServerSentEvent(c, "snapshot", snapshot); err != nil {
+			return nil
+		}
+
+		if len(failures) > 0 {
+			return nil
+		}
+
+		for _, tx := range txs {
+			if isTerminalStatus(tx.Type, tx.Status) {
+				return nil
+			}
+		}
+
+		heartbeat := time.NewTicker(trackStreamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(c.Response(), ": heartbeat\n\n"); err != nil {
+					return nil
+				}
+				c.Response().Flush()
+			case payload, ok := <-trackCh:
+				if !ok {
+					return nil
+				}
+
+				var statusEvent pub.StatusEvent
+				if err := json.Unmarshal([]byte(payload), &statusEvent); err != nil {
+					return err
+				}
+
+				if err := writeServerSentEvent(c, statusEvent.Event, []byte(payload)); err != nil {
+					return nil
+				}
+
+				if isTerminalStatus(statusEvent.Type, statusEvent.Status) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// markTrackAccepted lets the track stream of a queued request open before it is signed.
+// A failure only delays the stream until the otx is signed, it does not fail the request.
+func markTrackAccepted(c echo.Context, cu *custodial.Custodial, trackingIds ...string) {
+	if err := cu.MarkTrackAccepted(c.Request().Context(), trackingIds...); err != nil {
+		cu.Logg.Error("track: failed to mark tracking ids accepted", "tracking_ids", trackingIds, "error", err)
+	}
+}
+
+func writeServerSentEvent(c echo.Context, event string, data []byte) error {
+	if _, err := fmt.Fprintf(c.Response(), "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	c.Response().Flush()
+
+	return nil
+}
+
+// isTerminalStatus reports whether no further status change is expected for the tracking id.
+// Gas refills signed under a transfer's tracking id never end its stream.
+func isTerminalStatus(txType string, status string) bool {
+	if txType == string(enum.REFILL_GAS) {
+		return false
+	}
+
+	switch enum.OtxStatus(status) {
+	case enum.SUCCESS,
+		enum.REVERTED,
+		enum.REJECTED,
+		enum.FAIL_NO_GAS,
+		enum.FAIL_LOW_NONCE,
+		enum.FAIL_UNKNOWN_RPC_ERROR:
+		return true
+	default:
+		return false
+	}
+}
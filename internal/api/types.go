@@ -2,6 +2,10 @@ package api
 
 import "errors"
 
+const (
+	defaultPageSize = 20
+)
+
 var (
 	ErrInvalidJSON = errors.New("Invalid JSON structure.")
 )
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/labstack/echo/v4"
+)
+
+const voucherNotAllowedMessage = "Voucher not allowed."
+
+// HandleAdminGetVouchers godoc
+//
+//	@Summary		Get the voucher allowlist.
+//	@Description	Return the cached voucher allowlist, the manually allowlisted vouchers and the time of the last sync.
+//	@Description	Signing is only allowed against allowlisted vouchers.
+//	@Tags			admin
+//	@Accept			*/*
+//	@Produce		json
+//	@Success		200	{object}	OkResp
+//	@Failure		401	{object}	ErrResp
+//	@Failure		403	{object}	ErrResp
+//	@Failure		500	{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/admin/vouchers [get]
+func HandleAdminGetVouchers(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		vouchers, syncedAt, err := cu.GetVoucherAllowlist(c.Request().Context())
+		if err != nil {
+			return err
+		}
+
+		manualVouchers, err := cu.Store.GetVouchers(c.Request().Context())
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, OkResp{
+			Ok: true,
+			Result: H{
+				"vouchers":       vouchers,
+				"manualVouchers": manualVouchers,
+				"syncedAt":       syncedAt,
+			},
+		})
+	}
+}
+
+// HandleAdminAddVoucher godoc
+//
+//	@Summary		Allowlist a voucher.
+//	@Description	Manually allowlist a voucher which is not in the token index, it is allowed immediately.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			addVoucherRequest	body		object{voucherAddress=string,reason=string}	true	"Add Voucher Request"
+//	@Success		200					{object}	OkResp
+//	@Failure		400					{object}	ErrResp
+//	@Failure		401					{object}	ErrResp
+//	@Failure		403					{object}	ErrResp
+//	@Failure		500					{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/admin/vouchers [post]
+func HandleAdminAddVoucher(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		var (
+			req struct {
+				VoucherAddress string `json:"voucherAddress" validate:"required,eth_addr_checksum"`
+				Reason         string `json:"reason" validate:"required"`
+			}
+		)
+
+		if err := c.Bind(&req); err != nil {
+			return NewBadRequestError(ErrInvalidJSON)
+		}
+
+		if err := c.Validate(req); err != nil {
+			return err
+		}
+
+		added, err := cu.Store.AddVoucher(c.Request().Context(), store.Voucher{
+			VoucherAddress: req.VoucherAddress,
+			Reason:         req.Reason,
+			AddedBy:        ApiClient(c).Name,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := cu.AllowVoucher(c.Request().Context(), req.VoucherAddress); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, OkResp{
+			Ok: true,
+			Result: H{
+				"voucherAddress": req.VoucherAddress,
+				"added":          added,
+			},
+		})
+	}
+}
+
+// HandleAdminRemoveVoucher godoc
+//
+//	@Summary		Remove a manually allowlisted voucher.
+//	@Description	Remove a manually allowlisted voucher and resync the allowlist.
+//	@Description	A voucher in the token index stays allowed until it is removed from the index.
+//	@Tags			admin
+//	@Accept			*/*
+//	@Produce		json
+//	@Param			voucherAddress	path		string	true	"Voucher address"
+//	@Success		200				{object}	OkResp
+//	@Failure		401				{object}	ErrResp
+//	@Failure		403				{object}	ErrResp
+//	@Failure		404				{object}	ErrResp
+//	@Failure		500				{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/admin/vouchers/{voucherAddress} [delete]
+func HandleAdminRemoveVoucher(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		voucherAddress := c.Param("voucherAddress")
+
+		removed, err := cu.Store.RemoveVoucher(c.Request().Context(), voucherAddress)
+		if err != nil {
+			return err
+		}
+
+		if !removed {
+			return NewNotFoundError("Voucher is not manually allowlisted.")
+		}
+
+		if _, err := cu.SyncVoucherAllowlist(c.Request().Context()); err != nil {
+			return err
+		}
+
+		allowed, err := cu.IsVoucherAllowed(c.Request().Context(), voucherAddress)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, OkResp{
+			Ok: true,
+			Result: H{
+				"voucherAddress": voucherAddress,
+				"allowed":        allowed,
+			},
+		})
+	}
+}
+
+// HandleAdminSyncVouchers godoc
+//
+//	@Summary		Sync the voucher allowlist.
+//	@Description	Rebuild the voucher allowlist from the token index without waiting for the periodic sync.
+//	@Tags			admin
+//	@Accept			*/*
+//	@Produce		json
+//	@Success		200	{object}	OkResp
+//	@Failure		401	{object}	ErrResp
+//	@Failure		403	{object}	ErrResp
+//	@Failure		500	{object}	ErrResp
+//	@Security		ApiKeyAuth
+//	@Router			/admin/vouchers/sync [post]
+func HandleAdminSyncVouchers(cu *custodial.Custodial) func(echo.Context) error {
+	return func(c echo.Context) error {
+		count, err := cu.SyncVoucherAllowlist(c.Request().Context())
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, OkResp{
+			Ok: true,
+			Result: H{
+				"count": count,
+			},
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/tasker/task"
+	"github.com/labstack/echo/v4"
+)
+
+// checkCallbackUrl rejects a callback url if the API client has no webhook secret to sign deliveries with
+// or if the url points at a loopback, link-local or private host.
+func checkCallbackUrl(c echo.Context, callbackUrl string) error {
+	if callbackUrl == "" {
+		return nil
+	}
+
+	if !ApiClient(c).Webhooks {
+		return NewBadRequestError("Webhooks are not enabled for this API client.")
+	}
+
+	if err := task.CheckCallbackUrl(callbackUrl); err != nil {
+		return NewBadRequestError("Callback url must be a public http(s) url.")
+	}
+
+	return nil
+}
+
+// registerWebhook registers the callback url for the tracking id before its task is queued.
+func registerWebhook(c echo.Context, cu *custodial.Custodial, trackingId string, callbackUrl string) error {
+	if callbackUrl == "" {
+		return nil
+	}
+
+	return cu.Store.CreateWebhook(c.Request().Context(), trackingId, callbackUrl, ApiClient(c).Id)
+}
@@ -0,0 +1,169 @@
+// Package bootstrap loads the config and the components shared by the service and admin binaries.
+// Every loader exits through the logger on a critical error, there is nothing to recover at startup.
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/envelope"
+	"github.com/grassrootseconomics/cic-custodial/internal/nonce"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/cic-custodial/pkg/logg"
+	"github.com/grassrootseconomics/cic-custodial/pkg/redis"
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/providers/env"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"github.com/zerodha/logf"
+)
+
+// Logger loads the logger.
+func Logger(debug bool) logf.Logger {
+	loggOpts := logg.LoggOpts{}
+
+	if debug {
+		loggOpts.Color = true
+		loggOpts.Caller = true
+		loggOpts.Debug = true
+	}
+
+	return logg.NewLogg(loggOpts)
+}
+
+// Config loads the config file, CUSTODIAL_ prefixed env vars override it.
+func Config(lo logf.Logger, confFile string) *koanf.Koanf {
+	var (
+		ko = koanf.New(".")
+	)
+
+	if err := ko.Load(file.Provider(confFile), toml.Parser()); err != nil {
+		lo.Fatal("init: could not load config file", "error", err)
+	}
+
+	if err := ko.Load(env.Provider("CUSTODIAL_", ".", func(s string) string {
+		return strings.ReplaceAll(strings.ToLower(
+			strings.TrimPrefix(s, "CUSTODIAL_")), "__", ".")
+	}), nil); err != nil {
+		lo.Fatal("init: could not override config from env vars", "error", err)
+	}
+
+	return ko
+}
+
+// KeyProvider loads the master keys used to encrypt private keys at rest.
+// Without master keys private keys are stored as plaintext, which has to be explicitly allowed with keystore.allow_plaintext.
+// The remote and memory signers keep private keys out of the keystore and need no master keys.
+func KeyProvider(lo logf.Logger, ko *koanf.Koanf) envelope.KeyProvider {
+	masterKeysString := ko.String("keystore.master_keys")
+
+	if masterKeyFile := ko.String("keystore.master_key_file"); masterKeyFile != "" {
+		masterKeyFileContent, err := os.ReadFile(masterKeyFile)
+		if err != nil {
+			lo.Fatal("init: could not read master key file", "error", err)
+		}
+		masterKeysString += "\n" + string(masterKeyFileContent)
+	}
+
+	masterKeys, err := envelope.ParseMasterKeys(masterKeysString)
+	if err != nil {
+		lo.Fatal("init: could not parse master keys", "error", err)
+	}
+
+	if len(masterKeys) < 1 {
+		if backend := ko.String("signer.backend"); backend == "remote" || backend == "memory" {
+			return nil
+		}
+
+		if !ko.Bool("keystore.allow_plaintext") {
+			lo.Fatal("init: no master keys loaded, set keystore.master_keys or keystore.master_key_file or explicitly set keystore.allow_plaintext")
+		}
+
+		lo.Warn("init: no master keys loaded, private keys will be stored as plaintext")
+		return nil
+	}
+
+	keyProvider, err := envelope.NewLocalKeyProvider(envelope.LocalKeyProviderOpts{
+		MasterKeys: masterKeys,
+	})
+	if err != nil {
+		lo.Fatal("init: critical error loading key provider", "error", err)
+	}
+
+	return keyProvider
+}
+
+// CeloProvider loads the Celo chain provider.
+func CeloProvider(lo logf.Logger, ko *koanf.Koanf) *celoutils.Provider {
+	providerOpts := celoutils.ProviderOpts{
+		RpcEndpoint: ko.MustString("chain.rpc_endpoint"),
+	}
+
+	if ko.Bool("chain.testnet") {
+		providerOpts.ChainId = celoutils.TestnetChainId
+	} else {
+		providerOpts.ChainId = celoutils.MainnetChainId
+	}
+
+	provider, err := celoutils.NewProvider(providerOpts)
+	if err != nil {
+		lo.Fatal("init: critical error loading chain provider", "error", err)
+	}
+
+	return provider
+}
+
+// CommonRedisPool loads the common redis connection on a different db namespace from the tasker.
+func CommonRedisPool(lo logf.Logger, ko *koanf.Koanf) *redis.RedisPool {
+	poolOpts := redis.RedisPoolOpts{
+		DSN:          ko.MustString("redis.dsn"),
+		MinIdleConns: ko.MustInt("redis.min_idle_conn"),
+	}
+
+	pool, err := redis.NewRedisPool(context.Background(), poolOpts)
+	if err != nil {
+		lo.Fatal("init: critical error connecting to common redis db", "error", err)
+	}
+
+	return pool
+}
+
+// PgStore loads the Postgres store and runs the migrations.
+func PgStore(lo logf.Logger, ko *koanf.Koanf, keyProvider envelope.KeyProvider, migrationsFolder string, queriesFile string) store.Store {
+	store, err := store.NewPgStore(store.Opts{
+		DSN:                  ko.MustString("postgres.dsn"),
+		KeyProvider:          keyProvider,
+		MigrationsFolderPath: migrationsFolder,
+		QueriesFolderPath:    queriesFile,
+	})
+	if err != nil {
+		lo.Fatal("init: critical error loading Postgres store", "error", err)
+	}
+
+	return store
+}
+
+// Noncestore loads the configured noncestore backend.
+// A nil redisPool is only connected to if redis backs the noncestore.
+func Noncestore(lo logf.Logger, ko *koanf.Koanf, redisPool *redis.RedisPool, chainProvider *celoutils.Provider, store store.Store) nonce.Noncestore {
+	noncestoreOpts := nonce.Opts{
+		ChainProvider: chainProvider,
+		RedisPool:     redisPool,
+		Store:         store,
+	}
+
+	switch backend := ko.MustString("nonce.backend"); backend {
+	case "redis":
+		if noncestoreOpts.RedisPool == nil {
+			noncestoreOpts.RedisPool = CommonRedisPool(lo, ko)
+		}
+		return nonce.NewRedisNoncestore(noncestoreOpts)
+	case "postgres":
+		return nonce.NewPgNoncestore(noncestoreOpts)
+	default:
+		lo.Fatal("init: unknown noncestore backend", "backend", backend)
+		return nil
+	}
+}
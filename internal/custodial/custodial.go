@@ -2,16 +2,20 @@ package custodial
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"math/big"
 	"time"
 
 	"github.com/bsm/redislock"
 	"github.com/celo-org/celo-blockchain/common"
-	eth_crypto "github.com/celo-org/celo-blockchain/crypto"
 	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/feeoracle"
 	"github.com/grassrootseconomics/cic-custodial/internal/nonce"
+	"github.com/grassrootseconomics/cic-custodial/internal/policy"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/signer"
 	"github.com/grassrootseconomics/cic-custodial/internal/store"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
+	"github.com/grassrootseconomics/cic-custodial/internal/token"
 	"github.com/grassrootseconomics/cic-custodial/pkg/util"
 	"github.com/grassrootseconomics/w3-celo-patch"
 	"github.com/redis/go-redis/v9"
@@ -19,33 +23,70 @@ import (
 )
 
 type (
+	// Thresholds are CELO balances checked by the balance monitor, a zero threshold is disabled.
+	Thresholds struct {
+		// WarnSystemBalance logs a warning when a system account falls below it.
+		WarnSystemBalance float64
+		// PauseSystemBalance and PauseFaucetBalance pause account registration until the balance recovers.
+		PauseSystemBalance float64
+		PauseFaucetBalance float64
+		// LockSystemBalance engages the system global lock, it is not released automatically.
+		LockSystemBalance float64
+	}
+
 	Opts struct {
-		ApprovalTimeout  time.Duration
-		CeloProvider     *celoutils.Provider
-		LockProvider     *redislock.Client
-		Logg             logf.Logger
-		Noncestore       nonce.Noncestore
-		Store            store.Store
-		RedisClient      *redis.Client
-		RegistryAddress  string
-		SystemPrivateKey string
-		SystemPublicKey  string
-		TaskerClient     *tasker.TaskerClient
+		ApprovalTimeout time.Duration
+		CeloProvider    *celoutils.Provider
+		FeeOracle       *feeoracle.FeeOracle
+		GasBumpPercent  int64
+		GasLimitMargin  int64
+		LockProvider    *redislock.Client
+		Logg            logf.Logger
+		MaxGasFeeCap    int64
+		NonceRepair     bool
+		Policy          *policy.Policy
+		Noncestore      nonce.Noncestore
+		Pub             *pub.Pub
+		Store           store.Store
+		ReconcileWindow time.Duration
+		RedisClient     *redis.Client
+		RegistryAddress string
+		Signer          signer.Signer
+		StuckTxTimeout  time.Duration
+		SystemPool      []string
+		SystemPublicKey string
+		TaskerClient    *tasker.TaskerClient
+		Thresholds      Thresholds
+		TokenMetadata   *token.MetadataCache
 	}
 
 	Custodial struct {
-		ApprovalTimeout  time.Duration
-		Abis             map[string]*w3.Func
-		CeloProvider     *celoutils.Provider
-		LockProvider     *redislock.Client
-		Logg             logf.Logger
-		Noncestore       nonce.Noncestore
-		Store            store.Store
-		RedisClient      *redis.Client
-		RegistryMap      map[string]common.Address
-		SystemPrivateKey *ecdsa.PrivateKey
-		SystemPublicKey  string
-		TaskerClient     *tasker.TaskerClient
+		ApprovalTimeout time.Duration
+		Abis            map[string]*w3.Func
+		CeloProvider    *celoutils.Provider
+		FeeOracle       *feeoracle.FeeOracle
+		GasBumpPercent  int64
+		GasLimitMargin  int64
+		LockProvider    *redislock.Client
+		Logg            logf.Logger
+		MaxGasFeeCap    *big.Int
+		NonceRepair     bool
+		Policy          *policy.Policy
+		Noncestore      nonce.Noncestore
+		Pub             *pub.Pub
+		Store           store.Store
+		ReconcileWindow time.Duration
+		RedisClient     *redis.Client
+		RegistryMap     map[string]common.Address
+		Signer          signer.Signer
+		StuckTxTimeout  time.Duration
+		SystemPool      []string
+		SystemPublicKey string
+		TaskerClient    *tasker.TaskerClient
+		Thresholds      Thresholds
+		TokenMetadata   *token.MetadataCache
+
+		trackHub *trackHub
 	}
 )
 
@@ -59,31 +100,52 @@ func NewCustodial(o Opts) (*Custodial, error) {
 		return nil, err
 	}
 
-	systemNonce, err := o.Noncestore.Peek(ctx, o.SystemPublicKey)
-	if err != nil {
-		return nil, err
+	// The primary system account is always part of the system signer pool.
+	systemPool := []string{o.SystemPublicKey}
+	for _, publicKey := range o.SystemPool {
+		if publicKey != o.SystemPublicKey {
+			systemPool = append(systemPool, publicKey)
+		}
 	}
 
-	o.Logg.Info("custodial: loaded_nonce", "system_nonce", systemNonce)
+	for _, publicKey := range systemPool {
+		systemNonce, err := o.Noncestore.Peek(ctx, publicKey)
+		if err != nil {
+			return nil, err
+		}
 
-	privateKey, err := eth_crypto.HexToECDSA(o.SystemPrivateKey)
-	if err != nil {
-		return nil, err
+		o.Logg.Info("custodial: loaded_nonce", "system_account", publicKey, "system_nonce", systemNonce)
 	}
 
 	return &Custodial{
-		ApprovalTimeout:  o.ApprovalTimeout,
-		Abis:             initAbis(),
-		CeloProvider:     o.CeloProvider,
-		LockProvider:     o.LockProvider,
-		Logg:             o.Logg,
-		Noncestore:       o.Noncestore,
-		Store:            o.Store,
-		RedisClient:      o.RedisClient,
-		RegistryMap:      registryMap,
-		SystemPrivateKey: privateKey,
-		SystemPublicKey:  o.SystemPublicKey,
-		TaskerClient:     o.TaskerClient,
+		ApprovalTimeout: o.ApprovalTimeout,
+		Abis:            initAbis(),
+		CeloProvider:    o.CeloProvider,
+		FeeOracle:       o.FeeOracle,
+		GasBumpPercent:  o.GasBumpPercent,
+		GasLimitMargin:  o.GasLimitMargin,
+		LockProvider:    o.LockProvider,
+		Logg:            o.Logg,
+		MaxGasFeeCap:    big.NewInt(o.MaxGasFeeCap),
+		NonceRepair:     o.NonceRepair,
+		Policy:          o.Policy,
+		Noncestore:      o.Noncestore,
+		Pub:             o.Pub,
+		Store:           o.Store,
+		ReconcileWindow: o.ReconcileWindow,
+		RedisClient:     o.RedisClient,
+		RegistryMap:     registryMap,
+		Signer:          o.Signer,
+		StuckTxTimeout:  o.StuckTxTimeout,
+		SystemPool:      systemPool,
+		SystemPublicKey: o.SystemPublicKey,
+		TaskerClient:    o.TaskerClient,
+		Thresholds:      o.Thresholds,
+		TokenMetadata:   o.TokenMetadata,
+
+		trackHub: &trackHub{
+			subs: make(map[string]map[chan string]struct{}),
+		},
 	}, nil
 
 }
@@ -0,0 +1,141 @@
+package custodial
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	trackChannelPrefix = "track:"
+	trackAcceptedKey   = "track_accepted:"
+
+	// trackAcceptedTTL outlasts the retries of a queued task, after which its otx or failure is tracked instead.
+	trackAcceptedTTL = 24 * time.Hour
+	// trackSubBuffer is the number of status changes a slow track stream may fall behind before it is closed.
+	trackSubBuffer = 16
+)
+
+type (
+	// trackHub fans out the status changes of every tracking id from a single Redis pattern subscription per replica.
+	trackHub struct {
+		mu      sync.Mutex
+		started bool
+		subs    map[string]map[chan string]struct{}
+	}
+)
+
+// PublishTrackEvent fans out an otx status change to the track streams of every API replica over Redis pub/sub.
+func (c *Custodial) PublishTrackEvent(ctx context.Context, event pub.StatusEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return c.RedisClient.Publish(ctx, trackChannelPrefix+event.TrackingId, data).Err()
+}
+
+// MarkTrackAccepted records that requests were accepted under the tracking ids.
+// It lets a track stream tell a queued request that has no otx yet apart from an unknown tracking id.
+func (c *Custodial) MarkTrackAccepted(ctx context.Context, trackingIds ...string) error {
+	pipe := c.RedisClient.Pipeline()
+	for _, trackingId := range trackingIds {
+		pipe.Set(ctx, trackAcceptedKey+trackingId, 1, trackAcceptedTTL)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IsTrackAccepted checks whether a request was recently accepted under the tracking id.
+func (c *Custodial) IsTrackAccepted(ctx context.Context, trackingId string) (bool, error) {
+	n, err := c.RedisClient.Exists(ctx, trackAcceptedKey+trackingId).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// SubscribeTrack subscribes to the status changes of a tracking id, unsubscribe must be called once done.
+// The shared subscription is confirmed before it returns so that no later status change is missed.
+// The channel is closed if the subscriber falls too far behind.
+func (c *Custodial) SubscribeTrack(ctx context.Context, trackingId string) (<-chan string, func(), error) {
+	if err := c.trackHub.start(ctx, c); err != nil {
+		return nil, nil, err
+	}
+
+	ch := make(chan string, trackSubBuffer)
+
+	c.trackHub.mu.Lock()
+	if c.trackHub.subs[trackingId] == nil {
+		c.trackHub.subs[trackingId] = make(map[chan string]struct{})
+	}
+	c.trackHub.subs[trackingId][ch] = struct{}{}
+	c.trackHub.mu.Unlock()
+
+	return ch, func() {
+		c.trackHub.remove(trackingId, ch)
+	}, nil
+}
+
+// start opens the pattern subscription on first use, go-redis reconnects and resubscribes it if the connection drops.
+func (h *trackHub) start(ctx context.Context, c *Custodial) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.started {
+		return nil
+	}
+
+	pubSub := c.RedisClient.PSubscribe(context.Background(), trackChannelPrefix+"*")
+	if _, err := pubSub.Receive(ctx); err != nil {
+		pubSub.Close()
+		return err
+	}
+
+	h.started = true
+	go h.run(pubSub.Channel())
+
+	return nil
+}
+
+func (h *trackHub) run(msgCh <-chan *redis.Message) {
+	for msg := range msgCh {
+		trackingId := strings.TrimPrefix(msg.Channel, trackChannelPrefix)
+
+		h.mu.Lock()
+		for ch := range h.subs[trackingId] {
+			select {
+			case ch <- msg.Payload:
+			default:
+				h.removeLocked(trackingId, ch)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *trackHub) remove(trackingId string, ch chan string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.removeLocked(trackingId, ch)
+}
+
+func (h *trackHub) removeLocked(trackingId string, ch chan string) {
+	if _, ok := h.subs[trackingId][ch]; !ok {
+		return
+	}
+
+	delete(h.subs[trackingId], ch)
+	if len(h.subs[trackingId]) < 1 {
+		delete(h.subs, trackingId)
+	}
+	close(ch)
+}
@@ -0,0 +1,169 @@
+package custodial
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestTrackCustodial(t *testing.T) *Custodial {
+	t.Helper()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: miniredis.RunT(t).Addr(),
+	})
+	t.Cleanup(func() {
+		redisClient.Close()
+	})
+
+	return &Custodial{
+		RedisClient: redisClient,
+		trackHub: &trackHub{
+			subs: make(map[string]map[chan string]struct{}),
+		},
+	}
+}
+
+func receiveTrackEvent(t *testing.T, ch <-chan string) pub.StatusEvent {
+	t.Helper()
+
+	select {
+	case payload, ok := <-ch:
+		if !ok {
+			t.Fatal("track channel closed")
+		}
+
+		var statusEvent pub.StatusEvent
+		if err := json.Unmarshal([]byte(payload), &statusEvent); err != nil {
+			t.Fatal(err)
+		}
+
+		return statusEvent
+	case <-time.After(time.Second):
+		t.Fatal("no track event received")
+		return pub.StatusEvent{}
+	}
+}
+
+func TestTrackHub(t *testing.T) {
+	ctx := context.Background()
+	cu := newTestTrackCustodial(t)
+
+	firstCh, unsubscribeFirst, err := cu.SubscribeTrack(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secondCh, unsubscribeSecond, err := cu.SubscribeTrack(ctx, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribeSecond()
+
+	sameCh, unsubscribeSame, err := cu.SubscribeTrack(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribeSame()
+
+	event := pub.NewStatusEvent(pub.OtxSignedSubject, pub.OtxEvent{TrackingId: "first", TxHash: "0x1"})
+	if err := cu.PublishTrackEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ch := range []<-chan string{firstCh, sameCh} {
+		if got := receiveTrackEvent(t, ch); got.TxHash != "0x1" {
+			t.Fatalf("got tx hash %s, want 0x1", got.TxHash)
+		}
+	}
+
+	select {
+	case <-secondCh:
+		t.Fatal("received a status change of another tracking id")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unsubscribeFirst()
+	if _, ok := <-firstCh; ok {
+		t.Fatal("track channel open after unsubscribe")
+	}
+
+	// Unsubscribing twice is a no-op.
+	unsubscribeFirst()
+
+	if err := cu.PublishTrackEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := receiveTrackEvent(t, sameCh); got.TxHash != "0x1" {
+		t.Fatalf("got tx hash %s, want 0x1", got.TxHash)
+	}
+}
+
+func TestTrackHubSlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	cu := newTestTrackCustodial(t)
+
+	ch, unsubscribe, err := cu.SubscribeTrack(ctx, "slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+
+	for i := 0; i <= trackSubBuffer; i++ {
+		if err := cu.PublishTrackEvent(ctx, pub.NewStatusEvent(pub.OtxSignedSubject, pub.OtxEvent{TrackingId: "slow"})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing is read until the hub drops the subscriber for falling behind.
+	deadline := time.Now().Add(time.Second)
+	for {
+		cu.trackHub.mu.Lock()
+		_, subscribed := cu.trackHub.subs["slow"]
+		cu.trackHub.mu.Unlock()
+
+		if !subscribed {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("slow subscriber was not dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+
+	if received != trackSubBuffer {
+		t.Fatalf("received %d status changes before close, want %d", received, trackSubBuffer)
+	}
+}
+
+func TestTrackAccepted(t *testing.T) {
+	ctx := context.Background()
+	cu := newTestTrackCustodial(t)
+
+	if err := cu.MarkTrackAccepted(ctx, "first", "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	for trackingId, want := range map[string]bool{"first": true, "second": true, "unknown": false} {
+		accepted, err := cu.IsTrackAccepted(ctx, trackingId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if accepted != want {
+			t.Fatalf("tracking id %s accepted %v, want %v", trackingId, accepted, want)
+		}
+	}
+}
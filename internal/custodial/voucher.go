@@ -0,0 +1,236 @@
+package custodial
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/bsm/redislock"
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
+	"github.com/grassrootseconomics/w3-celo-patch/w3types"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	voucherAllowlistKey         = "system:voucher_allowlist"
+	voucherAllowlistSyncedAtKey = "system:voucher_allowlist_synced_at"
+	voucherAllowlistLockKey     = "lock:voucher_allowlist"
+
+	// voucherAllowlistLockTimeout covers a full token index sync, waiting requests give up after about as long.
+	voucherAllowlistLockTimeout    = 30 * time.Second
+	voucherAllowlistLockRetryDelay = 250 * time.Millisecond
+	voucherAllowlistLockRetries    = 120
+
+	// tokenIndexBatchSize is the number of token index entries fetched in a single batched RPC call.
+	tokenIndexBatchSize = 100
+)
+
+// IsVoucherAllowed checks a voucher against the cached allowlist.
+// If the allowlist was never synced or the cache was lost, a single sync runs across all replicas while other checks wait for it.
+// If the sync fails, manually allowlisted vouchers are still allowed from Postgres and any other voucher returns the sync error
+// so that the request is retried rather than rejected.
+func (c *Custodial) IsVoucherAllowed(ctx context.Context, voucherAddress string) (bool, error) {
+	synced, err := c.isVoucherAllowlistSynced(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if !synced {
+		if err := c.syncColdVoucherAllowlist(ctx); err != nil {
+			c.Logg.Warn("custodial: voucher allowlist sync failed, falling back to the manual allowlist", "error", err)
+
+			manualVouchers, mErr := c.Store.GetVouchers(ctx)
+			if mErr != nil {
+				return false, mErr
+			}
+
+			for _, voucher := range manualVouchers {
+				if voucher.VoucherAddress == voucherAddress {
+					return true, nil
+				}
+			}
+
+			return false, err
+		}
+	}
+
+	return c.RedisClient.SIsMember(ctx, voucherAllowlistKey, voucherAddress).Result()
+}
+
+func (c *Custodial) isVoucherAllowlistSynced(ctx context.Context) (bool, error) {
+	synced, err := c.RedisClient.Exists(ctx, voucherAllowlistSyncedAtKey).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return synced > 0, nil
+}
+
+// syncColdVoucherAllowlist syncs the allowlist unless another check is already syncing it, in which case it waits for that sync.
+func (c *Custodial) syncColdVoucherAllowlist(ctx context.Context) error {
+	for i := 0; i < voucherAllowlistLockRetries; i++ {
+		lock, err := c.LockProvider.Obtain(ctx, voucherAllowlistLockKey, voucherAllowlistLockTimeout, nil)
+		if err == nil {
+			defer lock.Release(ctx)
+
+			// Another check may have synced between the first check and the lock.
+			synced, err := c.isVoucherAllowlistSynced(ctx)
+			if err != nil || synced {
+				return err
+			}
+
+			_, err = c.syncVoucherAllowlist(ctx)
+			return err
+		}
+
+		if !errors.Is(err, redislock.ErrNotObtained) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(voucherAllowlistLockRetryDelay):
+		}
+
+		synced, err := c.isVoucherAllowlistSynced(ctx)
+		if err != nil || synced {
+			return err
+		}
+	}
+
+	return redislock.ErrNotObtained
+}
+
+// obtainVoucherAllowlistLock serializes allowlist syncs and manual additions across replicas.
+func (c *Custodial) obtainVoucherAllowlistLock(ctx context.Context) (*redislock.Lock, error) {
+	return c.LockProvider.Obtain(
+		ctx,
+		voucherAllowlistLockKey,
+		voucherAllowlistLockTimeout,
+		&redislock.Options{
+			RetryStrategy: redislock.LimitRetry(
+				redislock.LinearBackoff(voucherAllowlistLockRetryDelay),
+				voucherAllowlistLockRetries,
+			),
+		},
+	)
+}
+
+// GetVoucherAllowlist returns the cached allowlist and the time it was last synced.
+func (c *Custodial) GetVoucherAllowlist(ctx context.Context) ([]string, time.Time, error) {
+	vouchers, err := c.RedisClient.SMembers(ctx, voucherAllowlistKey).Result()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	syncedAt, err := c.RedisClient.Get(ctx, voucherAllowlistSyncedAtKey).Int64()
+	if err != nil && err != redis.Nil {
+		return nil, time.Time{}, err
+	}
+
+	return vouchers, time.Unix(syncedAt, 0).UTC(), nil
+}
+
+// AllowVoucher adds a voucher to the cached allowlist without waiting for the next sync.
+// It waits for a running sync so that the sync, which may have read the manual allowlist before the voucher was added, can't overwrite it.
+func (c *Custodial) AllowVoucher(ctx context.Context, voucherAddress string) error {
+	lock, err := c.obtainVoucherAllowlistLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer lock.Release(ctx)
+
+	return c.RedisClient.SAdd(ctx, voucherAllowlistKey, voucherAddress).Err()
+}
+
+// SyncVoucherAllowlist rebuilds the cached allowlist from the token index contract entries and the manually allowlisted vouchers.
+// It returns the number of allowlisted vouchers.
+func (c *Custodial) SyncVoucherAllowlist(ctx context.Context) (int, error) {
+	lock, err := c.obtainVoucherAllowlistLock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Release(ctx)
+
+	return c.syncVoucherAllowlist(ctx)
+}
+
+func (c *Custodial) syncVoucherAllowlist(ctx context.Context) (int, error) {
+	var (
+		entryCount big.Int
+		tokenIndex = c.RegistryMap[celoutils.TokenIndex]
+	)
+
+	if err := c.CeloProvider.Client.CallCtx(
+		ctx,
+		eth.CallFunc(c.Abis[EntryCount], tokenIndex).Returns(&entryCount),
+	); err != nil {
+		return 0, err
+	}
+
+	var (
+		total    = entryCount.Uint64()
+		vouchers = make(map[string]struct{}, total)
+	)
+
+	for start := uint64(0); start < total; start += tokenIndexBatchSize {
+		end := start + tokenIndexBatchSize
+		if end > total {
+			end = total
+		}
+
+		var (
+			entries = make([]common.Address, end-start)
+			calls   = make([]w3types.Caller, end-start)
+		)
+
+		for i := range entries {
+			calls[i] = eth.CallFunc(
+				c.Abis[Entry],
+				tokenIndex,
+				new(big.Int).SetUint64(start+uint64(i)),
+			).Returns(&entries[i])
+		}
+
+		if err := c.CeloProvider.Client.CallCtx(ctx, calls...); err != nil {
+			return 0, err
+		}
+
+		for _, entry := range entries {
+			vouchers[entry.Hex()] = struct{}{}
+		}
+	}
+
+	manualVouchers, err := c.Store.GetVouchers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, voucher := range manualVouchers {
+		vouchers[voucher.VoucherAddress] = struct{}{}
+	}
+
+	members := make([]interface{}, 0, len(vouchers))
+	for voucherAddress := range vouchers {
+		members = append(members, voucherAddress)
+	}
+
+	// The allowlist is swapped in a single transaction so that concurrent checks never see a partial list.
+	if _, err := c.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, voucherAllowlistKey)
+		if len(members) > 0 {
+			pipe.SAdd(ctx, voucherAllowlistKey, members...)
+		}
+		pipe.Set(ctx, voucherAllowlistSyncedAtKey, time.Now().Unix(), 0)
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return len(members), nil
+}
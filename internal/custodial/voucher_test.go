@@ -0,0 +1,307 @@
+package custodial
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bsm/redislock"
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/redis/go-redis/v9"
+	"github.com/zerodha/logf"
+)
+
+const testTokenIndexEntries = 150
+
+var testManualVoucher = common.HexToAddress("0x000000000000000000000000000000000000aBcD").Hex()
+
+// voucherStore serves a fixed manual allowlist.
+type voucherStore struct {
+	store.Store
+
+	vouchers []store.Voucher
+}
+
+func (s *voucherStore) GetVouchers(context.Context) ([]store.Voucher, error) {
+	return s.vouchers, nil
+}
+
+// testTokenIndex serves the token index entryCount and entry calls over JSON-RPC.
+type testTokenIndex struct {
+	funcs           map[string]tokenIndexFunc
+	entryCountCalls atomic.Int64
+	fail            atomic.Bool
+}
+
+// tokenIndexFunc encodes the return value of a token index function, entry takes the entry position.
+type tokenIndexFunc struct {
+	selector []byte
+	encode   func(uint64) ([]byte, error)
+}
+
+type rpcRequest struct {
+	Id     json.RawMessage   `json:"id"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  string          `json:"result"`
+}
+
+// testTokenIndexEntry is the voucher at a token index position.
+func testTokenIndexEntry(i uint64) common.Address {
+	return common.BigToAddress(new(big.Int).SetUint64(i + 1))
+}
+
+func newTestTokenIndex(t *testing.T) (*testTokenIndex, *httptest.Server) {
+	t.Helper()
+
+	abis := initAbis()
+	tokenIndex := &testTokenIndex{
+		funcs: map[string]tokenIndexFunc{
+			EntryCount: {
+				selector: abis[EntryCount].Selector[:],
+				encode: func(uint64) ([]byte, error) {
+					return abis[EntryCount].Returns.Pack(big.NewInt(testTokenIndexEntries))
+				},
+			},
+			Entry: {
+				selector: abis[Entry].Selector[:],
+				encode: func(i uint64) ([]byte, error) {
+					return abis[Entry].Returns.Pack(testTokenIndexEntry(i))
+				},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tokenIndex.fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		batch := bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+
+		var reqs []rpcRequest
+		if batch {
+			err = json.Unmarshal(body, &reqs)
+		} else {
+			reqs = make([]rpcRequest, 1)
+			err = json.Unmarshal(body, &reqs[0])
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resps := make([]rpcResponse, len(reqs))
+		for i, req := range reqs {
+			result, err := tokenIndex.call(req)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			resps[i] = rpcResponse{Jsonrpc: "2.0", Id: req.Id, Result: hexutil.Encode(result)}
+		}
+
+		if batch {
+			json.NewEncoder(w).Encode(resps)
+		} else {
+			json.NewEncoder(w).Encode(resps[0])
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return tokenIndex, server
+}
+
+func (ti *testTokenIndex) call(req rpcRequest) ([]byte, error) {
+	var msg struct {
+		Data  hexutil.Bytes `json:"data"`
+		Input hexutil.Bytes `json:"input"`
+	}
+	if err := json.Unmarshal(req.Params[0], &msg); err != nil {
+		return nil, err
+	}
+
+	input := msg.Input
+	if len(input) == 0 {
+		input = msg.Data
+	}
+
+	if bytes.HasPrefix(input, ti.funcs[EntryCount].selector) {
+		ti.entryCountCalls.Add(1)
+		return ti.funcs[EntryCount].encode(0)
+	}
+
+	return ti.funcs[Entry].encode(new(big.Int).SetBytes(input[4:]).Uint64())
+}
+
+func newTestVoucherCustodial(t *testing.T) (*Custodial, *testTokenIndex) {
+	t.Helper()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: miniredis.RunT(t).Addr(),
+	})
+	t.Cleanup(func() {
+		redisClient.Close()
+	})
+
+	tokenIndex, server := newTestTokenIndex(t)
+
+	celoProvider, err := celoutils.NewProvider(celoutils.ProviderOpts{
+		ChainId:     celoutils.TestnetChainId,
+		RpcEndpoint: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Custodial{
+		Abis:         initAbis(),
+		CeloProvider: celoProvider,
+		LockProvider: redislock.New(redisClient),
+		Logg:         logf.New(logf.Opts{Level: logf.FatalLevel}),
+		RedisClient:  redisClient,
+		RegistryMap: map[string]common.Address{
+			celoutils.TokenIndex: common.HexToAddress("0x00000000000000000000000000000000000000e1"),
+		},
+		Store: &voucherStore{
+			vouchers: []store.Voucher{{VoucherAddress: testManualVoucher}},
+		},
+	}, tokenIndex
+}
+
+func TestIsVoucherAllowedColdCache(t *testing.T) {
+	ctx := context.Background()
+	cu, tokenIndex := newTestVoucherCustodial(t)
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]bool, 20)
+		errs    = make([]error, len(results))
+	)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = cu.IsVoucherAllowed(ctx, testTokenIndexEntry(testTokenIndexEntries-1).Hex())
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+
+		if !results[i] {
+			t.Fatal("token index voucher not allowed")
+		}
+	}
+
+	if calls := tokenIndex.entryCountCalls.Load(); calls != 1 {
+		t.Fatalf("token index synced %d times, want 1", calls)
+	}
+
+	for voucherAddress, want := range map[string]bool{
+		testManualVoucher: true,
+		common.HexToAddress("0x000000000000000000000000000000000000dEaD").Hex(): false,
+	} {
+		allowed, err := cu.IsVoucherAllowed(ctx, voucherAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if allowed != want {
+			t.Fatalf("voucher %s allowed %v, want %v", voucherAddress, allowed, want)
+		}
+	}
+}
+
+func TestIsVoucherAllowedSyncFailure(t *testing.T) {
+	ctx := context.Background()
+	cu, tokenIndex := newTestVoucherCustodial(t)
+	tokenIndex.fail.Store(true)
+
+	allowed, err := cu.IsVoucherAllowed(ctx, testManualVoucher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allowed {
+		t.Fatal("manual voucher not allowed while the token index is unavailable")
+	}
+
+	// A token index voucher can't be checked, it is retried rather than rejected.
+	if _, err := cu.IsVoucherAllowed(ctx, testTokenIndexEntry(0).Hex()); err == nil {
+		t.Fatal("token index voucher checked while the token index is unavailable")
+	}
+}
+
+func TestAllowVoucherDuringSync(t *testing.T) {
+	ctx := context.Background()
+	cu, _ := newTestVoucherCustodial(t)
+
+	voucherAddress := common.HexToAddress("0x000000000000000000000000000000000000bEEF").Hex()
+
+	// Hold the lock as a running sync which read the manual allowlist before the voucher was added.
+	lock, err := cu.obtainVoucherAllowlistLock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allowErr := make(chan error, 1)
+	go func() {
+		allowErr <- cu.AllowVoucher(ctx, voucherAddress)
+	}()
+
+	select {
+	case err := <-allowErr:
+		t.Fatalf("voucher allowed during a sync: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if _, err := cu.syncVoucherAllowlist(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-allowErr; err != nil {
+		t.Fatal(err)
+	}
+
+	allowed, err := cu.IsVoucherAllowed(ctx, voucherAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allowed {
+		t.Fatal("voucher allowed during a sync was overwritten")
+	}
+}
@@ -0,0 +1,102 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+const dataKeySize = 32
+
+var ErrCiphertextTooShort = errors.New("envelope: ciphertext too short")
+
+type (
+	// KeyProvider wraps and unwraps per-record data keys with a versioned master key.
+	// The local provider keeps the master keys in memory, a HSM/KMS backend only has to implement this interface.
+	KeyProvider interface {
+		WrapKey(context.Context, []byte) ([]byte, uint, error)
+		UnwrapKey(context.Context, []byte, uint) ([]byte, error)
+	}
+
+	Sealed struct {
+		Ciphertext []byte
+		DataKey    []byte
+		KeyVersion uint
+	}
+)
+
+// Seal encrypts the plaintext with a fresh AES-256-GCM data key and wraps the data key with the current master key.
+// The ciphertext is bound to the additional data (e.g. the id of the record it is stored in), it only opens with the same additional data.
+func Seal(ctx context.Context, keyProvider KeyProvider, plaintext []byte, additionalData []byte) (Sealed, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Sealed{}, err
+	}
+
+	ciphertext, err := encrypt(dataKey, plaintext, additionalData)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	wrappedKey, keyVersion, err := keyProvider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	return Sealed{
+		Ciphertext: ciphertext,
+		DataKey:    wrappedKey,
+		KeyVersion: keyVersion,
+	}, nil
+}
+
+// Open unwraps the data key with the master key version it was sealed with and decrypts the ciphertext.
+// It fails if the additional data differs from the one the ciphertext was sealed with.
+func Open(ctx context.Context, keyProvider KeyProvider, sealed Sealed, additionalData []byte) ([]byte, error) {
+	dataKey, err := keyProvider.UnwrapKey(ctx, sealed.DataKey, sealed.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypt(dataKey, sealed.Ciphertext, additionalData)
+}
+
+// encrypt seals the plaintext with AES-GCM, the random nonce is prepended to the ciphertext.
+func encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
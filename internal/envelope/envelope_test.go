@@ -0,0 +1,71 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+)
+
+func newTestKeyProvider(t *testing.T) KeyProvider {
+	t.Helper()
+
+	masterKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(masterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	keyProvider, err := NewLocalKeyProvider(LocalKeyProviderOpts{
+		MasterKeys: map[uint][]byte{1: masterKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keyProvider
+}
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	keyProvider := newTestKeyProvider(t)
+	plaintext := []byte("private key")
+
+	tests := []struct {
+		name       string
+		sealData   []byte
+		openData   []byte
+		wantFailed bool
+	}{
+		{name: "same additional data", sealData: []byte("0x1"), openData: []byte("0x1")},
+		{name: "no additional data", sealData: nil, openData: nil},
+		{name: "different additional data", sealData: []byte("0x1"), openData: []byte("0x2"), wantFailed: true},
+		{name: "missing additional data", sealData: []byte("0x1"), openData: nil, wantFailed: true},
+		{name: "unexpected additional data", sealData: nil, openData: []byte("0x1"), wantFailed: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := Seal(ctx, keyProvider, plaintext, tt.sealData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opened, err := Open(ctx, keyProvider, sealed, tt.openData)
+			if tt.wantFailed {
+				if err == nil {
+					t.Fatal("opened with different additional data")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(opened, plaintext) {
+				t.Fatalf("got %q, want %q", opened, plaintext)
+			}
+		})
+	}
+}
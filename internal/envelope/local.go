@@ -0,0 +1,107 @@
+package envelope
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	LocalKeyProviderOpts struct {
+		// MasterKeys maps a key version to a 32 byte AES-256 key.
+		MasterKeys map[uint][]byte
+	}
+
+	// LocalKeyProvider implements `KeyProvider`
+	// New data keys are always wrapped with the highest master key version.
+	// Older versions are kept to unwrap data keys sealed before a rotation.
+	LocalKeyProvider struct {
+		currentVersion uint
+		masterKeys     map[uint][]byte
+	}
+)
+
+func NewLocalKeyProvider(o LocalKeyProviderOpts) (KeyProvider, error) {
+	if len(o.MasterKeys) < 1 {
+		return nil, fmt.Errorf("envelope: no master keys loaded")
+	}
+
+	var (
+		currentVersion uint
+	)
+
+	for version, key := range o.MasterKeys {
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("envelope: master key version %d must be %d bytes", version, dataKeySize)
+		}
+
+		if version > currentVersion {
+			currentVersion = version
+		}
+	}
+
+	return &LocalKeyProvider{
+		currentVersion: currentVersion,
+		masterKeys:     o.MasterKeys,
+	}, nil
+}
+
+func (p *LocalKeyProvider) WrapKey(_ context.Context, dataKey []byte) ([]byte, uint, error) {
+	wrappedKey, err := encrypt(p.masterKeys[p.currentVersion], dataKey, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return wrappedKey, p.currentVersion, nil
+}
+
+func (p *LocalKeyProvider) UnwrapKey(_ context.Context, wrappedKey []byte, version uint) ([]byte, error) {
+	masterKey, ok := p.masterKeys[version]
+	if !ok {
+		return nil, fmt.Errorf("envelope: master key version %d not loaded", version)
+	}
+
+	return decrypt(masterKey, wrappedKey, nil)
+}
+
+// ParseMasterKeys parses "version:hexkey" entries separated by commas or newlines.
+// Empty lines and lines starting with # are ignored.
+func ParseMasterKeys(s string) (map[uint][]byte, error) {
+	masterKeys := make(map[uint][]byte)
+
+	entries := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		versionString, keyString, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("envelope: master key entry must be formatted as version:hexkey")
+		}
+
+		version, err := strconv.ParseUint(versionString, 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("envelope: invalid master key version %q", versionString)
+		}
+
+		key, err := hex.DecodeString(strings.TrimPrefix(keyString, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("envelope: invalid master key version %d: %v", version, err)
+		}
+
+		if _, exists := masterKeys[uint(version)]; exists {
+			return nil, fmt.Errorf("envelope: duplicate master key version %d", version)
+		}
+
+		masterKeys[uint(version)] = key
+	}
+
+	return masterKeys, nil
+}
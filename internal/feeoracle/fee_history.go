@@ -0,0 +1,32 @@
+package feeoracle
+
+import (
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/rpc"
+)
+
+type (
+	feeHistory struct {
+		BaseFee []*hexutil.Big   `json:"baseFeePerGas"`
+		Reward  [][]*hexutil.Big `json:"reward"`
+	}
+
+	// feeHistoryCaller implements the w3types.Caller interface for eth_feeHistory which is not exposed by the eth module.
+	feeHistoryCaller struct {
+		blockCount uint64
+		percentile float64
+		returns    *feeHistory
+	}
+)
+
+func (c *feeHistoryCaller) CreateRequest() (rpc.BatchElem, error) {
+	return rpc.BatchElem{
+		Method: "eth_feeHistory",
+		Args:   []any{hexutil.Uint64(c.blockCount), "latest", []float64{c.percentile}},
+		Result: c.returns,
+	}, nil
+}
+
+func (c *feeHistoryCaller) HandleResponse(elem rpc.BatchElem) error {
+	return elem.Error
+}
@@ -0,0 +1,172 @@
+package feeoracle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/redis/go-redis/v9"
+	"github.com/zerodha/logf"
+)
+
+const (
+	feesCacheKey = "fee_oracle:fees"
+)
+
+type (
+	Opts struct {
+		BaseFeeMultiplier float64
+		CacheTTL          time.Duration
+		ChainProvider     *celoutils.Provider
+		HistoryBlocks     uint64
+		Logg              logf.Logger
+		MaxGasFeeCap      int64
+		MinGasTipCap      int64
+		RedisClient       *redis.Client
+		TipMultiplier     float64
+		TipPercentile     float64
+	}
+
+	// FeeOracle samples EIP-1559 fees from the network and caches them across all replicas.
+	FeeOracle struct {
+		baseFeeMultiplier *big.Float
+		cacheTTL          time.Duration
+		chainProvider     *celoutils.Provider
+		historyBlocks     uint64
+		logg              logf.Logger
+		maxGasFeeCap      *big.Int
+		minGasTipCap      *big.Int
+		redisClient       *redis.Client
+		tipMultiplier     *big.Float
+		tipPercentile     float64
+	}
+
+	Fees struct {
+		GasFeeCap *big.Int `json:"gasFeeCap"`
+		GasTipCap *big.Int `json:"gasTipCap"`
+	}
+)
+
+func NewFeeOracle(o Opts) *FeeOracle {
+	return &FeeOracle{
+		baseFeeMultiplier: big.NewFloat(o.BaseFeeMultiplier),
+		cacheTTL:          o.CacheTTL,
+		chainProvider:     o.ChainProvider,
+		historyBlocks:     o.HistoryBlocks,
+		logg:              o.Logg,
+		maxGasFeeCap:      big.NewInt(o.MaxGasFeeCap),
+		minGasTipCap:      big.NewInt(o.MinGasTipCap),
+		redisClient:       o.RedisClient,
+		tipMultiplier:     big.NewFloat(o.TipMultiplier),
+		tipPercentile:     o.TipPercentile,
+	}
+}
+
+// Fees returns the cached fees or samples fresh ones from the network.
+// If the network cannot be sampled, it falls back to the celoutils safe gas caps.
+func (f *FeeOracle) Fees(ctx context.Context) (Fees, error) {
+	var (
+		fees Fees
+	)
+
+	cachedFees, err := f.redisClient.Get(ctx, feesCacheKey).Bytes()
+	if err == nil {
+		if err := json.Unmarshal(cachedFees, &fees); err != nil {
+			return fees, err
+		}
+
+		return fees, nil
+	} else if !errors.Is(err, redis.Nil) {
+		return fees, err
+	}
+
+	fees, err = f.sample(ctx)
+	if err != nil {
+		f.logg.Error("feeoracle: could not sample network fees, falling back to safe gas caps", "error", err)
+		return Fees{
+			GasFeeCap: celoutils.SafeGasFeeCap,
+			GasTipCap: celoutils.SafeGasTipCap,
+		}, nil
+	}
+
+	feesValue, err := json.Marshal(fees)
+	if err != nil {
+		return fees, err
+	}
+
+	if err := f.redisClient.Set(ctx, feesCacheKey, feesValue, f.cacheTTL).Err(); err != nil {
+		return fees, err
+	}
+
+	return fees, nil
+}
+
+// sample computes the fees from the pending block base fee and the median priority fee (at the configured percentile) of recent blocks.
+// GasFeeCap = BaseFee * BaseFeeMultiplier + GasTipCap, capped at MaxGasFeeCap.
+func (f *FeeOracle) sample(ctx context.Context) (Fees, error) {
+	var (
+		history feeHistory
+	)
+
+	if err := f.chainProvider.Client.CallCtx(
+		ctx,
+		&feeHistoryCaller{
+			blockCount: f.historyBlocks,
+			percentile: f.tipPercentile,
+			returns:    &history,
+		},
+	); err != nil {
+		return Fees{}, err
+	}
+
+	if len(history.BaseFee) < 1 {
+		return Fees{}, errors.New("feeoracle: empty fee history")
+	}
+
+	// The last base fee is that of the next (pending) block.
+	baseFee := history.BaseFee[len(history.BaseFee)-1].ToInt()
+
+	var rewards []*big.Int
+	for _, blockReward := range history.Reward {
+		if len(blockReward) > 0 {
+			rewards = append(rewards, blockReward[0].ToInt())
+		}
+	}
+
+	gasTipCap := new(big.Int).Set(f.minGasTipCap)
+	if len(rewards) > 0 {
+		sort.Slice(rewards, func(i, j int) bool {
+			return rewards[i].Cmp(rewards[j]) < 0
+		})
+
+		medianTip := multiply(rewards[len(rewards)/2], f.tipMultiplier)
+		if medianTip.Cmp(gasTipCap) > 0 {
+			gasTipCap = medianTip
+		}
+	}
+
+	gasFeeCap := multiply(baseFee, f.baseFeeMultiplier)
+	gasFeeCap.Add(gasFeeCap, gasTipCap)
+
+	if gasFeeCap.Cmp(f.maxGasFeeCap) > 0 {
+		gasFeeCap.Set(f.maxGasFeeCap)
+	}
+
+	if gasTipCap.Cmp(gasFeeCap) > 0 {
+		gasTipCap.Set(gasFeeCap)
+	}
+
+	return Fees{
+		GasFeeCap: gasFeeCap,
+		GasTipCap: gasTipCap,
+	}, nil
+}
+
+func multiply(x *big.Int, multiplier *big.Float) *big.Int {
+	result, _ := new(big.Float).Mul(new(big.Float).SetInt(x), multiplier).Int(nil)
+	return result
+}
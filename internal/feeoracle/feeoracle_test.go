@@ -0,0 +1,240 @@
+package feeoracle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/redis/go-redis/v9"
+	"github.com/zerodha/logf"
+)
+
+type rpcRequest struct {
+	Id     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+}
+
+type rpcResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+// newTestFeeOracle serves eth_feeHistory with a fixed history, a nil history fails every call.
+func newTestFeeOracle(t *testing.T, history *feeHistory) (*FeeOracle, *atomic.Int64) {
+	t.Helper()
+
+	calls := new(atomic.Int64)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		if history == nil {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		batch := bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+
+		var reqs []rpcRequest
+		if batch {
+			err = json.Unmarshal(body, &reqs)
+		} else {
+			reqs = make([]rpcRequest, 1)
+			err = json.Unmarshal(body, &reqs[0])
+		}
+		if err != nil || reqs[0].Method != "eth_feeHistory" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		resp := rpcResponse{Jsonrpc: "2.0", Id: reqs[0].Id, Result: history}
+		if batch {
+			json.NewEncoder(w).Encode([]rpcResponse{resp})
+		} else {
+			json.NewEncoder(w).Encode(resp)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: miniredis.RunT(t).Addr(),
+	})
+	t.Cleanup(func() {
+		redisClient.Close()
+	})
+
+	chainProvider, err := celoutils.NewProvider(celoutils.ProviderOpts{
+		ChainId:     celoutils.TestnetChainId,
+		RpcEndpoint: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewFeeOracle(Opts{
+		BaseFeeMultiplier: 2,
+		CacheTTL:          time.Minute,
+		ChainProvider:     chainProvider,
+		HistoryBlocks:     4,
+		Logg:              logf.New(logf.Opts{Level: logf.FatalLevel}),
+		MaxGasFeeCap:      10_000,
+		MinGasTipCap:      100,
+		RedisClient:       redisClient,
+		TipMultiplier:     1.5,
+		TipPercentile:     50,
+	}), calls
+}
+
+func testFeeHistory(baseFees []int64, rewards ...int64) *feeHistory {
+	history := &feeHistory{
+		Reward: [][]*hexutil.Big{},
+	}
+
+	for _, baseFee := range baseFees {
+		history.BaseFee = append(history.BaseFee, (*hexutil.Big)(big.NewInt(baseFee)))
+	}
+
+	for _, reward := range rewards {
+		history.Reward = append(history.Reward, []*hexutil.Big{(*hexutil.Big)(big.NewInt(reward))})
+	}
+
+	return history
+}
+
+func TestFees(t *testing.T) {
+	tests := []struct {
+		name          string
+		history       *feeHistory
+		wantGasFeeCap *big.Int
+		wantGasTipCap *big.Int
+	}{
+		{
+			// Median of 100, 200, 300 is 200, 200 * 1.5 = 300, 1000 * 2 + 300 = 2300.
+			name:          "median tip",
+			history:       testFeeHistory([]int64{500, 1000}, 300, 100, 200),
+			wantGasFeeCap: big.NewInt(2300),
+			wantGasTipCap: big.NewInt(300),
+		},
+		{
+			// The upper median of an even sample, 300 * 1.5 = 450.
+			name:          "even sample",
+			history:       testFeeHistory([]int64{1000}, 400, 100, 300, 200),
+			wantGasFeeCap: big.NewInt(2450),
+			wantGasTipCap: big.NewInt(450),
+		},
+		{
+			name:          "fractional multiplier truncates",
+			history:       testFeeHistory([]int64{1001}, 201),
+			wantGasFeeCap: big.NewInt(2303),
+			wantGasTipCap: big.NewInt(301),
+		},
+		{
+			name:          "min tip floor",
+			history:       testFeeHistory([]int64{1000}, 10, 20, 30),
+			wantGasFeeCap: big.NewInt(2100),
+			wantGasTipCap: big.NewInt(100),
+		},
+		{
+			name:          "no rewards",
+			history:       testFeeHistory([]int64{1000}),
+			wantGasFeeCap: big.NewInt(2100),
+			wantGasTipCap: big.NewInt(100),
+		},
+		{
+			name:          "max fee cap clamp",
+			history:       testFeeHistory([]int64{6000}, 200),
+			wantGasFeeCap: big.NewInt(10_000),
+			wantGasTipCap: big.NewInt(300),
+		},
+		{
+			name:          "tip clamped to fee cap",
+			history:       testFeeHistory([]int64{1000}, 20_000),
+			wantGasFeeCap: big.NewInt(10_000),
+			wantGasTipCap: big.NewInt(10_000),
+		},
+		{
+			name:          "empty fee history",
+			history:       testFeeHistory(nil),
+			wantGasFeeCap: celoutils.SafeGasFeeCap,
+			wantGasTipCap: celoutils.SafeGasTipCap,
+		},
+		{
+			name:          "rpc failure",
+			wantGasFeeCap: celoutils.SafeGasFeeCap,
+			wantGasTipCap: celoutils.SafeGasTipCap,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			feeOracle, _ := newTestFeeOracle(t, tt.history)
+
+			fees, err := feeOracle.Fees(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if fees.GasFeeCap.Cmp(tt.wantGasFeeCap) != 0 {
+				t.Fatalf("got gas fee cap %s, want %s", fees.GasFeeCap, tt.wantGasFeeCap)
+			}
+
+			if fees.GasTipCap.Cmp(tt.wantGasTipCap) != 0 {
+				t.Fatalf("got gas tip cap %s, want %s", fees.GasTipCap, tt.wantGasTipCap)
+			}
+		})
+	}
+}
+
+func TestFeesCache(t *testing.T) {
+	ctx := context.Background()
+	feeOracle, calls := newTestFeeOracle(t, testFeeHistory([]int64{1000}, 200))
+
+	for i := 0; i < 3; i++ {
+		fees, err := feeOracle.Fees(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fees.GasFeeCap.Cmp(big.NewInt(2300)) != 0 {
+			t.Fatalf("got gas fee cap %s, want 2300", fees.GasFeeCap)
+		}
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("fee history sampled %d times, want 1", n)
+	}
+}
+
+func TestFeesFallbackNotCached(t *testing.T) {
+	ctx := context.Background()
+	feeOracle, calls := newTestFeeOracle(t, nil)
+
+	for i := 0; i < 2; i++ {
+		if _, err := feeOracle.Fees(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The safe caps are not cached, the network is sampled again on the next call.
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("fee history sampled %d times, want 2", n)
+	}
+}
@@ -0,0 +1,213 @@
+package keyarchive
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/accounts/keystore"
+	"github.com/celo-org/celo-blockchain/crypto"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Version is bumped on any breaking change to the archive or manifest format.
+	Version = 1
+
+	saltSize = 32
+	// Manifest signing key derivation parameters, the same as Web3 Secret Storage with the standard KDF.
+	scryptN      = keystore.StandardScryptN
+	scryptP      = keystore.StandardScryptP
+	scryptR      = 8
+	scryptKeyLen = 32
+)
+
+var (
+	ErrInvalidSignature   = errors.New("keyarchive: invalid manifest signature or passphrase")
+	ErrUnsupportedVersion = errors.New("keyarchive: unsupported archive version")
+	ErrKeyMismatch        = errors.New("keyarchive: key does not match its public key")
+)
+
+type (
+	// Archive is the exported file.
+	// The signature is a HMAC-SHA256 over the compact manifest JSON with a key derived from the passphrase and salt.
+	Archive struct {
+		Version   int             `json:"version"`
+		Salt      string          `json:"salt"`
+		Manifest  json.RawMessage `json:"manifest"`
+		Signature string          `json:"signature"`
+	}
+
+	Manifest struct {
+		CreatedAt time.Time `json:"createdAt"`
+		Accounts  []Account `json:"accounts"`
+	}
+
+	// Account holds a Web3 Secret Storage encrypted key along with its custodial state.
+	Account struct {
+		PublicKey string          `json:"publicKey"`
+		Active    bool            `json:"active"`
+		GasLocked bool            `json:"gasLocked"`
+		Keystore  json.RawMessage `json:"keystore"`
+	}
+
+	Entry struct {
+		PrivateKey *ecdsa.PrivateKey
+		Active     bool
+		GasLocked  bool
+	}
+
+	Opts struct {
+		// LightKDF trades key security for export speed, every key is otherwise derived with 256MB of memory.
+		LightKDF   bool
+		Passphrase string
+	}
+)
+
+// Seal encrypts every entry with the passphrase and signs the manifest.
+func Seal(entries []Entry, o Opts) ([]byte, error) {
+	var (
+		kdfN = keystore.StandardScryptN
+		kdfP = keystore.StandardScryptP
+	)
+
+	if o.LightKDF {
+		kdfN = keystore.LightScryptN
+		kdfP = keystore.LightScryptP
+	}
+
+	manifest := Manifest{
+		CreatedAt: time.Now().UTC(),
+		Accounts:  make([]Account, len(entries)),
+	}
+
+	for i, entry := range entries {
+		key := &keystore.Key{
+			Id:         uuid.New(),
+			Address:    crypto.PubkeyToAddress(entry.PrivateKey.PublicKey),
+			PrivateKey: entry.PrivateKey,
+		}
+
+		keyJSON, err := keystore.EncryptKey(key, o.Passphrase, kdfN, kdfP)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Accounts[i] = Account{
+			PublicKey: key.Address.Hex(),
+			Active:    entry.Active,
+			GasLocked: entry.GasLocked,
+			Keystore:  keyJSON,
+		}
+	}
+
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	signature, err := sign(manifestJSON, o.Passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(Archive{
+		Version:   Version,
+		Salt:      hex.EncodeToString(salt),
+		Manifest:  manifestJSON,
+		Signature: hex.EncodeToString(signature),
+	}, "", "  ")
+}
+
+// Open verifies the manifest signature and decrypts every account.
+// Each decrypted key must derive the public key it was exported under.
+func Open(archiveJSON []byte, passphrase string) ([]Entry, error) {
+	var (
+		archive  Archive
+		manifest Manifest
+	)
+
+	if err := json.Unmarshal(archiveJSON, &archive); err != nil {
+		return nil, err
+	}
+
+	if archive.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	salt, err := hex.DecodeString(archive.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := hex.DecodeString(archive.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	// The manifest is indented in the archive file.
+	compactManifest := new(bytes.Buffer)
+	if err := json.Compact(compactManifest, archive.Manifest); err != nil {
+		return nil, err
+	}
+
+	expectedSignature, err := sign(compactManifest.Bytes(), passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(signature, expectedSignature) {
+		return nil, ErrInvalidSignature
+	}
+
+	if err := json.Unmarshal(archive.Manifest, &manifest); err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, len(manifest.Accounts))
+
+	for i, account := range manifest.Accounts {
+		key, err := keystore.DecryptKey(account.Keystore, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("keyarchive: account %s: %w", account.PublicKey, err)
+		}
+
+		derivedAddress := crypto.PubkeyToAddress(key.PrivateKey.PublicKey)
+		if derivedAddress != key.Address || derivedAddress.Hex() != account.PublicKey {
+			return nil, fmt.Errorf("%w: account %s: key derives %s", ErrKeyMismatch, account.PublicKey, derivedAddress.Hex())
+		}
+
+		entries[i] = Entry{
+			PrivateKey: key.PrivateKey,
+			Active:     account.Active,
+			GasLocked:  account.GasLocked,
+		}
+	}
+
+	return entries, nil
+}
+
+func sign(manifest []byte, passphrase string, salt []byte) ([]byte, error) {
+	signingKey, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write(manifest)
+
+	return mac.Sum(nil), nil
+}
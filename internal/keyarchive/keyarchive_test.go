@@ -0,0 +1,174 @@
+package keyarchive
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/crypto"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+func newTestArchive(t *testing.T) ([]Entry, []byte) {
+	t.Helper()
+
+	entries := make([]Entry, 2)
+	for i := range entries {
+		privateKey, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries[i] = Entry{
+			PrivateKey: privateKey,
+			Active:     i == 0,
+			GasLocked:  i == 1,
+		}
+	}
+
+	archive, err := Seal(entries, Opts{
+		LightKDF:   true,
+		Passphrase: testPassphrase,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return entries, archive
+}
+
+// editArchive changes a sealed archive, the manifest is signed again if resign is set.
+func editArchive(t *testing.T, archiveJSON []byte, resign bool, edit func(*Archive, *Manifest)) []byte {
+	t.Helper()
+
+	var (
+		archive  Archive
+		manifest Manifest
+	)
+
+	if err := json.Unmarshal(archiveJSON, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(archive.Manifest, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	edit(&archive, &manifest)
+
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive.Manifest = manifestJSON
+
+	if resign {
+		salt, err := hex.DecodeString(archive.Salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature, err := sign(manifestJSON, testPassphrase, salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		archive.Signature = hex.EncodeToString(signature)
+	}
+
+	editedJSON, err := json.Marshal(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return editedJSON
+}
+
+func TestSealOpen(t *testing.T) {
+	entries, archive := newTestArchive(t)
+
+	opened, err := Open(archive, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(opened) != len(entries) {
+		t.Fatalf("got %d entries, want %d", len(opened), len(entries))
+	}
+
+	for i := range entries {
+		if !opened[i].PrivateKey.Equal(entries[i].PrivateKey) {
+			t.Fatalf("entry %d: private key does not match", i)
+		}
+
+		if opened[i].Active != entries[i].Active || opened[i].GasLocked != entries[i].GasLocked {
+			t.Fatalf("entry %d: got active %v gas locked %v, want %v %v", i, opened[i].Active, opened[i].GasLocked, entries[i].Active, entries[i].GasLocked)
+		}
+	}
+}
+
+func TestOpenRejected(t *testing.T) {
+	_, archive := newTestArchive(t)
+
+	tests := []struct {
+		name       string
+		archive    []byte
+		passphrase string
+		wantErrIs  error
+	}{
+		{
+			name:       "wrong passphrase",
+			archive:    archive,
+			passphrase: "wrong passphrase",
+			wantErrIs:  ErrInvalidSignature,
+		},
+		{
+			name: "tampered manifest",
+			archive: editArchive(t, archive, false, func(_ *Archive, manifest *Manifest) {
+				manifest.Accounts[1].GasLocked = false
+			}),
+			passphrase: testPassphrase,
+			wantErrIs:  ErrInvalidSignature,
+		},
+		{
+			name: "tampered signature",
+			archive: editArchive(t, archive, false, func(archive *Archive, _ *Manifest) {
+				archive.Signature = hex.EncodeToString(make([]byte, 32))
+			}),
+			passphrase: testPassphrase,
+			wantErrIs:  ErrInvalidSignature,
+		},
+		{
+			name: "unsupported version",
+			archive: editArchive(t, archive, false, func(archive *Archive, _ *Manifest) {
+				archive.Version = Version + 1
+			}),
+			passphrase: testPassphrase,
+			wantErrIs:  ErrUnsupportedVersion,
+		},
+		{
+			// Signed with the right passphrase, but each key is exported under the other account's public key.
+			name: "key does not match public key",
+			archive: editArchive(t, archive, true, func(_ *Archive, manifest *Manifest) {
+				manifest.Accounts[0].PublicKey, manifest.Accounts[1].PublicKey = manifest.Accounts[1].PublicKey, manifest.Accounts[0].PublicKey
+			}),
+			passphrase: testPassphrase,
+			wantErrIs:  ErrKeyMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Open(tt.archive, tt.passphrase)
+			if err == nil {
+				t.Fatalf("opened %d entries, want error", len(entries))
+			}
+
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
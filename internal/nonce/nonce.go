@@ -1,11 +1,42 @@
 package nonce
 
-import "context"
+import (
+	"context"
+	"errors"
+
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
+	"github.com/jackc/pgx/v5"
+)
 
 // Noncestore defines how a nonce store should be implemented for any storage backend.
+// Return on an account with no nonce is a no-op, there is no acquired nonce to hand back.
 type Noncestore interface {
 	Peek(context.Context, string) (uint64, error)
 	Acquire(context.Context, string) (uint64, error)
 	Return(context.Context, string) error
 	SetAccountNonce(context.Context, string, uint64) error
 }
+
+// bootstrapNonce loads the next nonce for an account that is missing from a noncestore.
+// It first uses the otx_sign table as a source of nonce values.
+// If the otx_sign table is corrupted, it can fallback to the network nonce.
+func bootstrapNonce(ctx context.Context, chainProvider *celoutils.Provider, store store.Store, publicKey string) (uint64, error) {
+	lastDbNonce, err := store.GetNextNonce(ctx, publicKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err := chainProvider.Client.CallCtx(
+				ctx,
+				eth.Nonce(celoutils.HexToAddress(publicKey), nil).Returns(&lastDbNonce),
+			)
+			if err != nil {
+				return 0, err
+			}
+		} else {
+			return 0, err
+		}
+	}
+
+	return lastDbNonce, nil
+}
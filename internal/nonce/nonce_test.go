@@ -0,0 +1,213 @@
+package nonce
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+)
+
+const (
+	testAccount   = "0x0000000000000000000000000000000000000001"
+	testDbNonce   = 100
+	testWorkers   = 50
+	testBootDelay = 20 * time.Millisecond
+)
+
+type (
+	// bootstrapStore serves the otx_sign bootstrap nonce, every other store call goes to the embedded store.
+	bootstrapStore struct {
+		store.Store
+
+		nextNonce uint64
+		delay     time.Duration
+		calls     atomic.Int64
+	}
+
+	noncestoreOp int
+
+	noncestoreStep struct {
+		op    noncestoreOp
+		nonce uint64
+		want  uint64
+	}
+)
+
+const (
+	peekOp noncestoreOp = iota
+	acquireOp
+	returnOp
+	setOp
+)
+
+func (s *bootstrapStore) GetNextNonce(ctx context.Context, publicKey string) (uint64, error) {
+	s.calls.Add(1)
+	time.Sleep(s.delay)
+
+	return s.nextNonce, nil
+}
+
+// testNoncestore is the conformance suite every Noncestore implementation must pass.
+// The noncestore must bootstrap missing accounts to testDbNonce.
+func testNoncestore(t *testing.T, ns Noncestore) {
+	tests := []struct {
+		name  string
+		steps []noncestoreStep
+	}{
+		{
+			name: "peek bootstraps a missing account",
+			steps: []noncestoreStep{
+				{op: peekOp, want: testDbNonce},
+				{op: peekOp, want: testDbNonce},
+			},
+		},
+		{
+			name: "acquire bootstraps a missing account and increments",
+			steps: []noncestoreStep{
+				{op: acquireOp, want: testDbNonce},
+				{op: acquireOp, want: testDbNonce + 1},
+				{op: peekOp, want: testDbNonce + 2},
+			},
+		},
+		{
+			name: "return hands the last nonce out again",
+			steps: []noncestoreStep{
+				{op: acquireOp, want: testDbNonce},
+				{op: acquireOp, want: testDbNonce + 1},
+				{op: returnOp},
+				{op: peekOp, want: testDbNonce + 1},
+				{op: acquireOp, want: testDbNonce + 1},
+			},
+		},
+		{
+			name: "return never goes below 0",
+			steps: []noncestoreStep{
+				{op: setOp, nonce: 0},
+				{op: returnOp},
+				{op: peekOp, want: 0},
+				{op: acquireOp, want: 0},
+			},
+		},
+		{
+			name: "return on a missing account is a no-op",
+			steps: []noncestoreStep{
+				{op: returnOp},
+				{op: peekOp, want: testDbNonce},
+				{op: acquireOp, want: testDbNonce},
+			},
+		},
+		{
+			name: "set account nonce overrides a bootstrapped account",
+			steps: []noncestoreStep{
+				{op: acquireOp, want: testDbNonce},
+				{op: setOp, nonce: 42},
+				{op: peekOp, want: 42},
+				{op: acquireOp, want: 42},
+				{op: peekOp, want: 43},
+			},
+		},
+		{
+			name: "set account nonce on a missing account skips the bootstrap",
+			steps: []noncestoreStep{
+				{op: setOp, nonce: 5},
+				{op: acquireOp, want: 5},
+				{op: peekOp, want: 6},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				account = newTestAccount(t)
+			)
+
+			for i, step := range tt.steps {
+				var (
+					got uint64
+					err error
+				)
+
+				switch step.op {
+				case peekOp:
+					got, err = ns.Peek(ctx, account)
+				case acquireOp:
+					got, err = ns.Acquire(ctx, account)
+				case returnOp:
+					err = ns.Return(ctx, account)
+				case setOp:
+					err = ns.SetAccountNonce(ctx, account, step.nonce)
+				}
+
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+
+				if (step.op == peekOp || step.op == acquireOp) && got != step.want {
+					t.Fatalf("step %d: got nonce %d, want %d", i, got, step.want)
+				}
+			}
+		})
+	}
+
+	t.Run("concurrent acquire hands out every nonce once", func(t *testing.T) {
+		var (
+			ctx     = context.Background()
+			account = newTestAccount(t)
+			wg      sync.WaitGroup
+
+			nonces = make([]uint64, testWorkers)
+			errs   = make([]error, testWorkers)
+		)
+
+		for i := 0; i < testWorkers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				nonces[i], errs[i] = ns.Acquire(ctx, account)
+			}(i)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		assertContiguous(t, nonces, testDbNonce)
+	})
+}
+
+// newTestAccount returns a random account address so that runs against a persistent db never collide.
+func newTestAccount(t *testing.T) string {
+	t.Helper()
+
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	return "0x" + hex.EncodeToString(b)
+}
+
+// assertContiguous checks that the nonces are unique and form a contiguous range starting at from.
+func assertContiguous(t *testing.T, nonces []uint64, from uint64) {
+	t.Helper()
+
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+
+	for i, nonce := range nonces {
+		if nonce != from+uint64(i) {
+			t.Fatalf("nonce %d handed out at position %d, want %d (nonces: %v)", nonce, i, from+uint64(i), nonces)
+		}
+	}
+}
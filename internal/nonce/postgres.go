@@ -0,0 +1,79 @@
+package nonce
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/jackc/pgx/v5"
+)
+
+// PgNoncestore implements `Noncestore`
+// It is an alternative to the RedisNoncestore for deployments without a persistent redis.
+// Acquire and Return lock the account's nonce row so that concurrent callers are serialized.
+type PgNoncestore struct {
+	chainProvider *celoutils.Provider
+	store         store.Store
+}
+
+func NewPgNoncestore(o Opts) Noncestore {
+	return &PgNoncestore{
+		chainProvider: o.ChainProvider,
+		store:         o.Store,
+	}
+}
+
+func (n *PgNoncestore) Peek(ctx context.Context, publicKey string) (uint64, error) {
+	nonce, err := n.store.PeekNonce(ctx, publicKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if err := n.bootstrap(ctx, publicKey); err != nil {
+				return 0, err
+			}
+
+			return n.store.PeekNonce(ctx, publicKey)
+		}
+		return 0, err
+	}
+
+	return nonce, nil
+}
+
+func (n *PgNoncestore) Acquire(ctx context.Context, publicKey string) (uint64, error) {
+	nonce, err := n.store.AcquireNonce(ctx, publicKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if err := n.bootstrap(ctx, publicKey); err != nil {
+				return 0, err
+			}
+
+			return n.store.AcquireNonce(ctx, publicKey)
+		}
+		return 0, err
+	}
+
+	return nonce, nil
+}
+
+func (n *PgNoncestore) Return(ctx context.Context, publicKey string) error {
+	if err := n.store.ReturnNonce(ctx, publicKey); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	return nil
+}
+
+func (n *PgNoncestore) SetAccountNonce(ctx context.Context, publicKey string, nonce uint64) error {
+	return n.store.SetNonce(ctx, publicKey, nonce)
+}
+
+// bootstrap seeds a missing nonce row from the otx_sign table or the network.
+func (n *PgNoncestore) bootstrap(ctx context.Context, publicKey string) error {
+	nonce, err := bootstrapNonce(ctx, n.chainProvider, n.store, publicKey)
+	if err != nil {
+		return err
+	}
+
+	return n.store.InitNonce(ctx, publicKey, nonce)
+}
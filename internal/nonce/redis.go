@@ -2,13 +2,10 @@ package nonce
 
 import (
 	"context"
-	"errors"
 
 	"github.com/grassrootseconomics/celoutils"
 	"github.com/grassrootseconomics/cic-custodial/internal/store"
 	redispool "github.com/grassrootseconomics/cic-custodial/pkg/redis"
-	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
-	"github.com/jackc/pgx/v5"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -27,6 +24,47 @@ type (
 	}
 )
 
+// Every read-modify-write on a nonce key runs as a single Lua script so that the noncestore is safe without the per-account lock.
+// A missing key is signaled to the caller as redis.Nil, the caller then bootstraps the value and retries with it as ARGV[1].
+// The bootstrap value is only used if the key is still missing when the script runs, a concurrent bootstrap always wins.
+var (
+	// peekScript returns the next nonce.
+	peekScript = redis.NewScript(`
+local nonce = redis.call('GET', KEYS[1])
+if not nonce then
+	if not ARGV[1] then
+		return false
+	end
+	redis.call('SET', KEYS[1], ARGV[1])
+	nonce = ARGV[1]
+end
+return tonumber(nonce)
+`)
+
+	// acquireScript returns the next nonce and increments it.
+	acquireScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	if not ARGV[1] then
+		return false
+	end
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return redis.call('INCR', KEYS[1]) - 1
+`)
+
+	// returnScript decrements the next nonce, it never goes below 0.
+	returnScript = redis.NewScript(`
+local nonce = redis.call('GET', KEYS[1])
+if not nonce then
+	return false
+end
+if tonumber(nonce) > 0 then
+	return redis.call('DECR', KEYS[1])
+end
+return 0
+`)
+)
+
 func NewRedisNoncestore(o Opts) Noncestore {
 	return &RedisNoncestore{
 		chainProvider: o.ChainProvider,
@@ -36,61 +74,18 @@ func NewRedisNoncestore(o Opts) Noncestore {
 }
 
 func (n *RedisNoncestore) Peek(ctx context.Context, publicKey string) (uint64, error) {
-	nonce, err := n.redis.Client.Get(ctx, publicKey).Uint64()
-	if err != nil {
-		if err == redis.Nil {
-			nonce, err = n.bootstrap(ctx, publicKey)
-			if err != nil {
-				return 0, err
-			}
-
-			return nonce, nil
-		} else {
-			return 0, err
-		}
-	}
-
-	return nonce, nil
+	return n.runWithBootstrap(ctx, peekScript, publicKey)
 }
 
 func (n *RedisNoncestore) Acquire(ctx context.Context, publicKey string) (uint64, error) {
-	var (
-		nonce uint64
-	)
-
-	nonce, err := n.redis.Client.Get(ctx, publicKey).Uint64()
-	if err != nil {
-		if err == redis.Nil {
-			nonce, err = n.bootstrap(ctx, publicKey)
-			if err != nil {
-				return 0, err
-			}
-		} else {
-			return 0, err
-		}
-	}
-
-	err = n.redis.Client.Incr(ctx, publicKey).Err()
-	if err != nil {
-		return 0, err
-	}
-
-	return nonce, nil
+	return n.runWithBootstrap(ctx, acquireScript, publicKey)
 }
 
 func (n *RedisNoncestore) Return(ctx context.Context, publicKey string) error {
-	nonce, err := n.redis.Client.Get(ctx, publicKey).Uint64()
-	if err != nil {
+	if err := returnScript.Run(ctx, n.redis.Client, []string{publicKey}).Err(); err != nil && err != redis.Nil {
 		return err
 	}
 
-	if nonce > 0 {
-		err = n.redis.Client.Decr(ctx, publicKey).Err()
-		if err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
@@ -102,29 +97,27 @@ func (n *RedisNoncestore) SetAccountNonce(ctx context.Context, publicKey string,
 	return nil
 }
 
-// bootstrap can be used to restore a destroyed redis nonce cache automatically.
-// It first uses the otx_sign table as a source of nonce values.
-// If the otx_sign table is corrupted, it can fallback to the network nonce.
-// Ideally, the redis nonce cache should never be lost.
-func (n *RedisNoncestore) bootstrap(ctx context.Context, publicKey string) (uint64, error) {
-	lastDbNonce, err := n.store.GetNextNonce(ctx, publicKey)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			err := n.chainProvider.Client.CallCtx(
-				ctx,
-				eth.Nonce(celoutils.HexToAddress(publicKey), nil).Returns(&lastDbNonce),
-			)
-			if err != nil {
-				return 0, err
-			}
-		} else {
-			return 0, err
-		}
+// runWithBootstrap runs a nonce script and bootstraps the nonce if the key does not exist.
+func (n *RedisNoncestore) runWithBootstrap(ctx context.Context, script *redis.Script, publicKey string) (uint64, error) {
+	nonce, err := script.Run(ctx, n.redis.Client, []string{publicKey}).Uint64()
+	if err == nil {
+		return nonce, nil
+	}
+
+	if err != redis.Nil {
+		return 0, err
 	}
 
-	if err := n.SetAccountNonce(ctx, publicKey, lastDbNonce); err != nil {
+	bootstrapNonce, err := n.bootstrap(ctx, publicKey)
+	if err != nil {
 		return 0, err
 	}
 
-	return lastDbNonce, nil
+	return script.Run(ctx, n.redis.Client, []string{publicKey}, bootstrapNonce).Uint64()
+}
+
+// bootstrap can be used to restore a destroyed redis nonce cache automatically.
+// Ideally, the redis nonce cache should never be lost.
+func (n *RedisNoncestore) bootstrap(ctx context.Context, publicKey string) (uint64, error) {
+	return bootstrapNonce(ctx, n.chainProvider, n.store, publicKey)
 }
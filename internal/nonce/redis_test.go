@@ -0,0 +1,230 @@
+package nonce
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bsm/redislock"
+	redispool "github.com/grassrootseconomics/cic-custodial/pkg/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisNoncestore(t *testing.T) (Noncestore, *bootstrapStore, *redis.Client) {
+	t.Helper()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: miniredis.RunT(t).Addr(),
+	})
+	t.Cleanup(func() {
+		redisClient.Close()
+	})
+
+	bootstrapStore := &bootstrapStore{
+		nextNonce: testDbNonce,
+		delay:     testBootDelay,
+	}
+
+	return NewRedisNoncestore(Opts{
+		RedisPool: &redispool.RedisPool{Client: redisClient},
+		Store:     bootstrapStore,
+	}), bootstrapStore, redisClient
+}
+
+func TestRedisNoncestore(t *testing.T) {
+	noncestore, _, _ := newTestRedisNoncestore(t)
+
+	testNoncestore(t, noncestore)
+}
+
+func TestRedisNoncestoreConcurrentAcquire(t *testing.T) {
+	var (
+		ctx = context.Background()
+		wg  sync.WaitGroup
+
+		nonces = make([]uint64, testWorkers)
+		errs   = make([]error, testWorkers)
+	)
+
+	noncestore, bootstrapStore, _ := newTestRedisNoncestore(t)
+
+	// Every worker starts on a missing key, so the bootstrap from the db runs while other workers are acquiring.
+	for i := 0; i < testWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			nonces[i], errs[i] = noncestore.Acquire(ctx, testAccount)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if bootstrapStore.calls.Load() < 1 {
+		t.Fatal("nonce was not bootstrapped from the db")
+	}
+
+	assertContiguous(t, nonces, testDbNonce)
+
+	next, err := noncestore.Peek(ctx, testAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next != testDbNonce+testWorkers {
+		t.Fatalf("next nonce is %d, want %d", next, testDbNonce+testWorkers)
+	}
+}
+
+func TestRedisNoncestoreConcurrentAcquireReturn(t *testing.T) {
+	var (
+		ctx = context.Background()
+		wg  sync.WaitGroup
+
+		acquired atomic.Int64
+		returned atomic.Int64
+		errs     = make([]error, 2*testWorkers)
+	)
+
+	noncestore, _, _ := newTestRedisNoncestore(t)
+
+	// A return on a missing key is a no-op, bootstrap first so that every return is applied.
+	if _, err := noncestore.Peek(ctx, testAccount); err != nil {
+		t.Fatal(err)
+	}
+
+	// Acquires and returns interleave freely, every update must be applied exactly once.
+	for i := 0; i < 2*testWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if i%2 == 0 {
+				if _, errs[i] = noncestore.Acquire(ctx, testAccount); errs[i] == nil {
+					acquired.Add(1)
+				}
+				return
+			}
+
+			if errs[i] = noncestore.Return(ctx, testAccount); errs[i] == nil {
+				returned.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next, err := noncestore.Peek(ctx, testAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The bootstrap nonce is above testWorkers, so the floor at 0 never swallows a return.
+	want := uint64(testDbNonce + acquired.Load() - returned.Load())
+	if next != want {
+		t.Fatalf("next nonce is %d after %d acquires and %d returns, want %d", next, acquired.Load(), returned.Load(), want)
+	}
+}
+
+func TestRedisNoncestoreLockedAcquireReturn(t *testing.T) {
+	var (
+		ctx = context.Background()
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+
+		kept []uint64
+		errs = make([]error, testWorkers)
+	)
+
+	noncestore, bootstrapStore, redisClient := newTestRedisNoncestore(t)
+	lockProvider := redislock.New(redisClient)
+
+	// This mirrors the sign tasks: the nonce is acquired under the account lock and returned if signing fails.
+	// Every other worker fails to sign, the nonces that are kept must never be reused.
+	for i := 0; i < testWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			lock, err := lockProvider.Obtain(ctx, "lock:"+testAccount, time.Second, &redislock.Options{
+				RetryStrategy: redislock.LinearBackoff(time.Millisecond),
+			})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer lock.Release(ctx)
+
+			nonce, err := noncestore.Acquire(ctx, testAccount)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			if i%2 == 1 {
+				errs[i] = noncestore.Return(ctx, testAccount)
+				return
+			}
+
+			mu.Lock()
+			kept = append(kept, nonce)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if bootstrapStore.calls.Load() != 1 {
+		t.Fatalf("nonce was bootstrapped %d times, want 1", bootstrapStore.calls.Load())
+	}
+
+	assertContiguous(t, kept, testDbNonce)
+}
+
+func TestRedisNoncestoreReturnFloor(t *testing.T) {
+	ctx := context.Background()
+
+	noncestore, _, _ := newTestRedisNoncestore(t)
+
+	if err := noncestore.SetAccountNonce(ctx, testAccount, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < testWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := noncestore.Return(ctx, testAccount); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	next, err := noncestore.Peek(ctx, testAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next != 0 {
+		t.Fatalf("next nonce is %d after concurrent returns, want 0", next)
+	}
+}
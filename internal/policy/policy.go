@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/jackc/pgx/v5"
+)
+
+type (
+	Opts struct {
+		Store store.Store
+	}
+
+	// Policy enforces the transfer_policy rules on custodial account transfers.
+	Policy struct {
+		store store.Store
+	}
+)
+
+func NewPolicy(o Opts) *Policy {
+	return &Policy{
+		store: o.Store,
+	}
+}
+
+// CheckTransfer returns a non-empty reason naming the violated rule if the transfer is not allowed.
+// Usage is counted from the transfers already signed, a transfer queued but not yet signed is not counted
+// and the check must be repeated before signing.
+func (p *Policy) CheckTransfer(ctx context.Context, from string, voucherAddress string, amount *big.Int) (string, error) {
+	return p.check(ctx, from, voucherAddress, amount, true)
+}
+
+// CheckApproval returns a non-empty reason naming the violated rule if the approval is not allowed.
+// The spender can pull the whole allowance, so it is checked as a transfer of the approved amount against
+// max_per_transfer and max_per_day and counts toward the daily value. It is not counted as a transfer per hour.
+// Revoking an allowance is always allowed.
+func (p *Policy) CheckApproval(ctx context.Context, authorizer string, voucherAddress string, amount *big.Int) (string, error) {
+	if amount.Sign() == 0 {
+		return "", nil
+	}
+
+	return p.check(ctx, authorizer, voucherAddress, amount, false)
+}
+
+func (p *Policy) check(ctx context.Context, from string, voucherAddress string, amount *big.Int, isTransfer bool) (string, error) {
+	transferPolicy, err := p.store.GetTransferPolicy(ctx, from, voucherAddress)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	// Value limits are in voucher base units, they have no consistent meaning across vouchers with different decimals.
+	// They are only enforced on voucher specific policies, new voucher agnostic ones are rejected by the db.
+	if transferPolicy.VoucherAddress == nil {
+		transferPolicy.MaxPerTransfer = nil
+		transferPolicy.MaxPerDay = nil
+	}
+
+	if transferPolicy.MaxPerTransfer != nil && amount.Cmp(transferPolicy.MaxPerTransfer) > 0 {
+		return fmt.Sprintf(
+			"Transfer policy %d violated: max per transfer is %s.",
+			transferPolicy.Id,
+			transferPolicy.MaxPerTransfer,
+		), nil
+	}
+
+	checkHourCount := isTransfer && transferPolicy.MaxTransfersPerHour != nil
+
+	if transferPolicy.MaxPerDay == nil && !checkHourCount {
+		return "", nil
+	}
+
+	// A voucher specific policy only counts transfers of that voucher, otherwise only the transfer count is used.
+	var usageVoucher string
+	if transferPolicy.VoucherAddress != nil {
+		usageVoucher = voucherAddress
+	}
+
+	transferUsage, err := p.store.GetTransferUsage(ctx, from, usageVoucher)
+	if err != nil {
+		return "", err
+	}
+
+	if transferPolicy.MaxPerDay != nil && new(big.Int).Add(transferUsage.DayValue, amount).Cmp(transferPolicy.MaxPerDay) > 0 {
+		return fmt.Sprintf(
+			"Transfer policy %d violated: max per day is %s, %s already transferred in the last 24 hours.",
+			transferPolicy.Id,
+			transferPolicy.MaxPerDay,
+			transferUsage.DayValue,
+		), nil
+	}
+
+	if checkHourCount && transferUsage.HourCount+1 > *transferPolicy.MaxTransfersPerHour {
+		return fmt.Sprintf(
+			"Transfer policy %d violated: max transfers per hour is %d.",
+			transferPolicy.Id,
+			*transferPolicy.MaxTransfersPerHour,
+		), nil
+	}
+
+	return "", nil
+}
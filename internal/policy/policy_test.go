@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+)
+
+// policyStore serves a single transfer policy and usage.
+type policyStore struct {
+	store.Store
+
+	policy store.TransferPolicy
+	usage  store.TransferUsage
+}
+
+func (s *policyStore) GetTransferPolicy(context.Context, string, string) (store.TransferPolicy, error) {
+	return s.policy, nil
+}
+
+func (s *policyStore) GetTransferUsage(context.Context, string, string) (store.TransferUsage, error) {
+	return s.usage, nil
+}
+
+func TestPolicy(t *testing.T) {
+	var (
+		maxTransfersPerHour = uint64(2)
+		voucherAddress      = "0x0000000000000000000000000000000000000001"
+	)
+
+	newPolicy := func(policyVoucher *string) *Policy {
+		return NewPolicy(Opts{
+			Store: &policyStore{
+				policy: store.TransferPolicy{
+					Id:                  1,
+					VoucherAddress:      policyVoucher,
+					MaxPerTransfer:      big.NewInt(100),
+					MaxPerDay:           big.NewInt(250),
+					MaxTransfersPerHour: &maxTransfersPerHour,
+				},
+				usage: store.TransferUsage{
+					DayValue:  big.NewInt(200),
+					HourCount: 2,
+				},
+			},
+		})
+	}
+
+	var (
+		voucherPolicy = newPolicy(&voucherAddress)
+		globalPolicy  = newPolicy(nil)
+	)
+
+	tests := []struct {
+		name       string
+		policy     *Policy
+		approval   bool
+		amount     int64
+		wantReject bool
+	}{
+		{name: "transfer over max per hour", policy: voucherPolicy, amount: 10, wantReject: true},
+		{name: "approval is not counted per hour", policy: voucherPolicy, approval: true, amount: 10},
+		{name: "approval over max per transfer", policy: voucherPolicy, approval: true, amount: 101, wantReject: true},
+		{name: "approval over max per day", policy: voucherPolicy, approval: true, amount: 60, wantReject: true},
+		{name: "revoke", policy: voucherPolicy, approval: true, amount: 0},
+		{name: "global policy transfer over max per hour", policy: globalPolicy, amount: 10, wantReject: true},
+		{name: "global policy ignores value limits", policy: globalPolicy, approval: true, amount: 1000},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			check := tt.policy.CheckTransfer
+			if tt.approval {
+				check = tt.policy.CheckApproval
+			}
+
+			reason, err := check(context.Background(), "0x0", voucherAddress, big.NewInt(tt.amount))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (reason != "") != tt.wantReject {
+				t.Fatalf("got reason %q, want reject %v", reason, tt.wantReject)
+			}
+		})
+	}
+}
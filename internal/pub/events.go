@@ -0,0 +1,97 @@
+package pub
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	AlertSubject = "CUSTODIAL.alert"
+
+	// Otx lifecycle subjects.
+	OtxSignedSubject         = "CUSTODIAL.otx_signed"
+	OtxDispatchedSubject     = "CUSTODIAL.otx_dispatched"
+	OtxDispatchFailedSubject = "CUSTODIAL.otx_dispatch_failed"
+	OtxSuccessSubject        = "CUSTODIAL.otx_success"
+	OtxRevertedSubject       = "CUSTODIAL.otx_reverted"
+	// A request rejected before an otx could be signed, it has no tx hash.
+	OtxRejectedSubject = "CUSTODIAL.otx_rejected"
+
+	// Account lifecycle subjects.
+	AccountActivatedSubject = "CUSTODIAL.account_activated"
+	GasLockedSubject        = "CUSTODIAL.gas_locked"
+	GasUnlockedSubject      = "CUSTODIAL.gas_unlocked"
+)
+
+type (
+	AlertEvent struct {
+		Action    string  `json:"action"`
+		Account   string  `json:"account"`
+		Balance   float64 `json:"balance"`
+		Threshold float64 `json:"threshold"`
+		Timestamp int64   `json:"timestamp"`
+	}
+
+	OtxEvent struct {
+		TrackingId string `json:"trackingId"`
+		TxHash     string `json:"transactionHash"`
+		Type       string `json:"txType"`
+		From       string `json:"from"`
+		Nonce      uint64 `json:"nonce"`
+		Status     string `json:"status,omitempty"`
+		Block      uint64 `json:"block,omitempty"`
+		Reason     string `json:"reason,omitempty"`
+		Timestamp  int64  `json:"timestamp"`
+	}
+
+	// StatusEvent is an otx event tagged with its lifecycle event name (the subject without the stream prefix).
+	// It is the payload of webhooks and track streams.
+	StatusEvent struct {
+		Event string `json:"event"`
+		OtxEvent
+	}
+
+	AccountEvent struct {
+		PublicKey  string `json:"publicKey"`
+		TrackingId string `json:"trackingId,omitempty"`
+		TxHash     string `json:"transactionHash,omitempty"`
+		Timestamp  int64  `json:"timestamp"`
+	}
+)
+
+const (
+	AlertRegistrationPaused  = "REGISTRATION_PAUSED"
+	AlertRegistrationResumed = "REGISTRATION_RESUMED"
+	AlertGlobalLock          = "GLOBAL_LOCK"
+)
+
+// PublishOtxEvent publishes an otx lifecycle event.
+func (p *Pub) PublishOtxEvent(subject string, event OtxEvent) error {
+	event.Timestamp = time.Now().Unix()
+	return p.Publish(subject, OtxEventDedupId(subject, event), event)
+}
+
+// PublishAccountEvent publishes an account lifecycle event.
+func (p *Pub) PublishAccountEvent(subject string, event AccountEvent) error {
+	event.Timestamp = time.Now().Unix()
+	return p.Publish(subject, AccountEventDedupId(subject, event), event)
+}
+
+// OtxEventDedupId is the dedup id of an otx lifecycle event.
+// An otx passes through each lifecycle subject at most once so the subject, tracking id and tx hash form the dedup id.
+func OtxEventDedupId(subject string, event OtxEvent) string {
+	return subject + ":" + event.TrackingId + ":" + event.TxHash
+}
+
+// AccountEventDedupId is the dedup id of an account lifecycle event.
+// It is built from the tracking id or tx hash which caused the change.
+func AccountEventDedupId(subject string, event AccountEvent) string {
+	return subject + ":" + event.PublicKey + ":" + event.TrackingId + event.TxHash
+}
+
+func NewStatusEvent(subject string, event OtxEvent) StatusEvent {
+	return StatusEvent{
+		Event:    strings.TrimPrefix(subject, pushStream+"."),
+		OtxEvent: event,
+	}
+}
@@ -0,0 +1,62 @@
+package pub
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	pushStream         = "CUSTODIAL"
+	pushStreamSubjects = "CUSTODIAL.*"
+)
+
+type (
+	PubOpts struct {
+		DedupDuration   time.Duration
+		JsCtx           nats.JetStreamContext
+		PersistDuration time.Duration
+	}
+
+	Pub struct {
+		jsCtx nats.JetStreamContext
+	}
+)
+
+// NewPub creates the CUSTODIAL stream if it does not exist.
+// Messages published with the same dedup id within the dedup window are dropped by the server.
+func NewPub(o PubOpts) (*Pub, error) {
+	stream, _ := o.JsCtx.StreamInfo(pushStream)
+	if stream == nil {
+		_, err := o.JsCtx.AddStream(&nats.StreamConfig{
+			Name:       pushStream,
+			MaxAge:     o.PersistDuration,
+			Storage:    nats.FileStorage,
+			Subjects:   []string{pushStreamSubjects},
+			Duplicates: o.DedupDuration,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Pub{
+		jsCtx: o.JsCtx,
+	}, nil
+}
+
+// Publish JSON encodes the event and publishes it to the subject with the dedup id.
+func (p *Pub) Publish(subject string, dedupId string, event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.jsCtx.Publish(subject, data, nats.MsgId(dedupId))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
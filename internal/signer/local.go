@@ -0,0 +1,57 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/cic-custodial/pkg/keypair"
+)
+
+type (
+	LocalSignerOpts struct {
+		ChainSigner types.Signer
+		Store       store.Store
+		// SystemKeys maps each system account public key to its hex private key.
+		SystemKeys map[string]string
+	}
+
+	// LocalSigner implements `Signer`
+	// Account keys are loaded from the keystore table and the system keys from config.
+	LocalSigner struct {
+		chainSigner types.Signer
+		store       store.Store
+		systemKeys  map[string]*ecdsa.PrivateKey
+	}
+)
+
+func NewLocalSigner(o LocalSignerOpts) (Signer, error) {
+	systemKeys, err := parseSystemKeys(o.SystemKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalSigner{
+		chainSigner: o.ChainSigner,
+		store:       o.Store,
+		systemKeys:  systemKeys,
+	}, nil
+}
+
+func (s *LocalSigner) NewAccount(_ context.Context) (keypair.Key, error) {
+	return keypair.Generate()
+}
+
+func (s *LocalSigner) SignTx(ctx context.Context, from string, txData *types.DynamicFeeTx) (*types.Transaction, error) {
+	key, ok := s.systemKeys[from]
+	if !ok {
+		var err error
+		key, err = s.store.LoadPrivateKey(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return types.SignNewTx(key, s.chainSigner, txData)
+}
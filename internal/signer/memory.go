@@ -0,0 +1,69 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+
+	"github.com/celo-org/celo-blockchain/core/types"
+	eth_crypto "github.com/celo-org/celo-blockchain/crypto"
+	"github.com/grassrootseconomics/cic-custodial/pkg/keypair"
+)
+
+type (
+	MemorySignerOpts struct {
+		ChainSigner types.Signer
+		// SystemKeys maps each system account public key to its hex private key.
+		SystemKeys map[string]string
+	}
+
+	// MemorySigner implements `Signer`
+	// Keys only live in memory and are lost on restart, it is intended for offline development and testing.
+	MemorySigner struct {
+		chainSigner types.Signer
+		keys        map[string]*ecdsa.PrivateKey
+		mu          sync.RWMutex
+	}
+)
+
+func NewMemorySigner(o MemorySignerOpts) (Signer, error) {
+	keys, err := parseSystemKeys(o.SystemKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemorySigner{
+		chainSigner: o.ChainSigner,
+		keys:        keys,
+	}, nil
+}
+
+func (s *MemorySigner) NewAccount(_ context.Context) (keypair.Key, error) {
+	key, err := eth_crypto.GenerateKey()
+	if err != nil {
+		return keypair.Key{}, err
+	}
+
+	publicKey := eth_crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	s.mu.Lock()
+	s.keys[publicKey] = key
+	s.mu.Unlock()
+
+	return keypair.Key{
+		Public: publicKey,
+	}, nil
+}
+
+func (s *MemorySigner) SignTx(_ context.Context, from string, txData *types.DynamicFeeTx) (*types.Transaction, error) {
+	s.mu.RLock()
+	key, ok := s.keys[from]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("signer: no key for %s", from)
+	}
+
+	return types.SignNewTx(key, s.chainSigner, txData)
+}
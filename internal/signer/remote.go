@@ -0,0 +1,160 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/grassrootseconomics/cic-custodial/pkg/keypair"
+)
+
+const maxRemoteResponseSize = 1 << 16
+
+type (
+	RemoteSignerOpts struct {
+		AuthToken   string
+		ChainSigner types.Signer
+		Endpoint    string
+		Timeout     time.Duration
+	}
+
+	// RemoteSigner implements `Signer`
+	// Keys are generated and held by an external signing daemon, they never enter the custodial process.
+	// The daemon exposes:
+	//	POST /accounts	-> {"address": "0x..."}
+	//	POST /sign		{"address": "0x...", "chainId": 42220, "tx": "0x<unsigned EIP-1559 tx>"} -> {"signedTx": "0x..."}
+	RemoteSigner struct {
+		authToken   string
+		chainSigner types.Signer
+		endpoint    string
+		httpClient  *http.Client
+	}
+
+	remoteAccountResponse struct {
+		Address string `json:"address"`
+	}
+
+	remoteSignRequest struct {
+		Address string        `json:"address"`
+		ChainId *hexutil.Big  `json:"chainId"`
+		Tx      hexutil.Bytes `json:"tx"`
+	}
+
+	remoteSignResponse struct {
+		SignedTx hexutil.Bytes `json:"signedTx"`
+	}
+)
+
+func NewRemoteSigner(o RemoteSignerOpts) Signer {
+	return &RemoteSigner{
+		authToken:   o.AuthToken,
+		chainSigner: o.ChainSigner,
+		endpoint:    strings.TrimSuffix(o.Endpoint, "/"),
+		httpClient: &http.Client{
+			Timeout: o.Timeout,
+		},
+	}
+}
+
+func (s *RemoteSigner) NewAccount(ctx context.Context) (keypair.Key, error) {
+	var (
+		res remoteAccountResponse
+	)
+
+	if err := s.post(ctx, "/accounts", nil, &res); err != nil {
+		return keypair.Key{}, err
+	}
+
+	if !common.IsHexAddress(res.Address) {
+		return keypair.Key{}, fmt.Errorf("signer: remote returned invalid address %q", res.Address)
+	}
+
+	// Public keys are stored checksummed.
+	return keypair.Key{
+		Public: common.HexToAddress(res.Address).Hex(),
+	}, nil
+}
+
+// SignTx sends the unsigned tx to the signing daemon.
+// The signed tx is only accepted if it signs the exact tx data and recovers to the requested account.
+func (s *RemoteSigner) SignTx(ctx context.Context, from string, txData *types.DynamicFeeTx) (*types.Transaction, error) {
+	var (
+		res remoteSignResponse
+	)
+
+	unsignedTx := types.NewTx(txData)
+
+	unsignedTxBytes, err := unsignedTx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.post(ctx, "/sign", remoteSignRequest{
+		Address: from,
+		ChainId: (*hexutil.Big)(s.chainSigner.ChainID()),
+		Tx:      unsignedTxBytes,
+	}, &res); err != nil {
+		return nil, err
+	}
+
+	signedTx := new(types.Transaction)
+	if err := signedTx.UnmarshalBinary(res.SignedTx); err != nil {
+		return nil, err
+	}
+
+	if s.chainSigner.Hash(signedTx) != s.chainSigner.Hash(unsignedTx) {
+		return nil, fmt.Errorf("signer: remote signed a different tx for %s", from)
+	}
+
+	sender, err := types.Sender(s.chainSigner, signedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	if sender.Hex() != from {
+		return nil, fmt.Errorf("signer: remote signed as %s not %s", sender.Hex(), from)
+	}
+
+	return signedTx, nil
+}
+
+func (s *RemoteSigner) post(ctx context.Context, path string, reqBody any, resBody any) error {
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+path, bytes.NewReader(reqBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.authToken)
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(io.LimitReader(res.Body, maxRemoteResponseSize))
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("signer: remote %s failed with %d: %s", path, res.StatusCode, strings.TrimSpace(string(resBytes)))
+	}
+
+	return json.Unmarshal(resBytes, resBody)
+}
@@ -0,0 +1,65 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/celo-org/celo-blockchain/core/types"
+	eth_crypto "github.com/celo-org/celo-blockchain/crypto"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/pkg/keypair"
+)
+
+// Signer defines how accounts are created and transactions signed for any key backend.
+type Signer interface {
+	// NewAccount creates a new key.
+	// The private key is left empty if the key never leaves the signer backend.
+	NewAccount(context.Context) (keypair.Key, error)
+	// SignTx signs the tx data as the account.
+	SignTx(context.Context, string, *types.DynamicFeeTx) (*types.Transaction, error)
+}
+
+// ContractExecutionTx builds the unsigned tx data for a contract call.
+func ContractExecutionTx(o celoutils.ContractExecutionTxOpts) *types.DynamicFeeTx {
+	return &types.DynamicFeeTx{
+		To:        &o.ContractAddress,
+		Nonce:     o.Nonce,
+		Data:      o.InputData,
+		Gas:       o.GasLimit,
+		GasFeeCap: o.GasFeeCap,
+		GasTipCap: o.GasTipCap,
+	}
+}
+
+// GasTransferTx builds the unsigned tx data for a gas token transfer.
+func GasTransferTx(o celoutils.GasTransferTxOpts) *types.DynamicFeeTx {
+	return &types.DynamicFeeTx{
+		Value:     o.Value,
+		To:        &o.To,
+		Nonce:     o.Nonce,
+		Gas:       21000,
+		GasFeeCap: o.GasFeeCap,
+		GasTipCap: o.GasTipCap,
+	}
+}
+
+// parseSystemKeys parses the hex private key of every system account and checks that it derives the account public key.
+func parseSystemKeys(systemKeys map[string]string) (map[string]*ecdsa.PrivateKey, error) {
+	keys := make(map[string]*ecdsa.PrivateKey, len(systemKeys))
+
+	for publicKey, privateKeyString := range systemKeys {
+		privateKey, err := eth_crypto.HexToECDSA(privateKeyString)
+		if err != nil {
+			return nil, fmt.Errorf("signer: system account %s: %w", publicKey, err)
+		}
+
+		if derived := eth_crypto.PubkeyToAddress(privateKey.PublicKey).Hex(); derived != publicKey {
+			return nil, fmt.Errorf("signer: system private key derives %s not %s", derived, publicKey)
+		}
+
+		keys[publicKey] = privateKey
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,315 @@
+package signer
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/core/types"
+	eth_crypto "github.com/celo-org/celo-blockchain/crypto"
+)
+
+const testAuthToken = "test-token"
+
+var testChainSigner = types.LatestSignerForChainID(big.NewInt(44787))
+
+func newTestTxData(nonce uint64) *types.DynamicFeeTx {
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	return &types.DynamicFeeTx{
+		To:        &to,
+		Nonce:     nonce,
+		Data:      []byte{0xa9, 0x05, 0x9c, 0xbb},
+		Gas:       100000,
+		GasFeeCap: big.NewInt(10000000000),
+		GasTipCap: big.NewInt(5),
+	}
+}
+
+// newTestSystemKey returns a system account public key and hex private key.
+func newTestSystemKey(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := eth_crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return eth_crypto.PubkeyToAddress(key.PublicKey).Hex(), hex.EncodeToString(eth_crypto.FromECDSA(key))
+}
+
+// assertSignedBy checks that the tx signs the exact tx data and recovers to the account.
+func assertSignedBy(t *testing.T, tx *types.Transaction, txData *types.DynamicFeeTx, account string) {
+	t.Helper()
+
+	sender, err := types.Sender(testChainSigner, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sender.Hex() != account {
+		t.Fatalf("tx signed by %s, want %s", sender.Hex(), account)
+	}
+
+	if testChainSigner.Hash(tx) != testChainSigner.Hash(types.NewTx(txData)) {
+		t.Fatal("signed tx does not match the tx data")
+	}
+}
+
+func newTestMemorySigner(t *testing.T, systemKeys map[string]string) Signer {
+	t.Helper()
+
+	memorySigner, err := NewMemorySigner(MemorySignerOpts{
+		ChainSigner: testChainSigner,
+		SystemKeys:  systemKeys,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return memorySigner
+}
+
+func TestSystemKeys(t *testing.T) {
+	publicKey, privateKey := newTestSystemKey(t)
+	otherPublicKey, _ := newTestSystemKey(t)
+
+	tests := []struct {
+		name       string
+		systemKeys map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "matching keys",
+			systemKeys: map[string]string{publicKey: privateKey},
+		},
+		{
+			name:       "private key of another account",
+			systemKeys: map[string]string{otherPublicKey: privateKey},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid private key",
+			systemKeys: map[string]string{publicKey: "0xnothex"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, localErr := NewLocalSigner(LocalSignerOpts{
+				ChainSigner: testChainSigner,
+				SystemKeys:  tt.systemKeys,
+			})
+			_, memoryErr := NewMemorySigner(MemorySignerOpts{
+				ChainSigner: testChainSigner,
+				SystemKeys:  tt.systemKeys,
+			})
+
+			if (localErr != nil) != tt.wantErr || (memoryErr != nil) != tt.wantErr {
+				t.Fatalf("got local error %v and memory error %v, want error %v", localErr, memoryErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLocalSignerSystemKey(t *testing.T) {
+	publicKey, privateKey := newTestSystemKey(t)
+
+	// System keys never hit the keystore, the store is not needed.
+	localSigner, err := NewLocalSigner(LocalSignerOpts{
+		ChainSigner: testChainSigner,
+		SystemKeys:  map[string]string{publicKey: privateKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txData := newTestTxData(1)
+
+	tx, err := localSigner.SignTx(context.Background(), publicKey, txData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertSignedBy(t, tx, txData, publicKey)
+}
+
+func TestMemorySigner(t *testing.T) {
+	ctx := context.Background()
+
+	systemPublicKey, systemPrivateKey := newTestSystemKey(t)
+	memorySigner := newTestMemorySigner(t, map[string]string{systemPublicKey: systemPrivateKey})
+
+	account, err := memorySigner.NewAccount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.Private != "" {
+		t.Fatal("memory signer private key left the signer")
+	}
+
+	for _, from := range []string{systemPublicKey, account.Public} {
+		txData := newTestTxData(2)
+
+		tx, err := memorySigner.SignTx(ctx, from, txData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertSignedBy(t, tx, txData, from)
+	}
+
+	unknownPublicKey, _ := newTestSystemKey(t)
+	if _, err := memorySigner.SignTx(ctx, unknownPublicKey, newTestTxData(3)); err == nil {
+		t.Fatal("memory signer signed for an unknown account")
+	}
+}
+
+// newTestSigningDaemon serves the remote signer protocol, tamper can alter the unsigned tx before it is signed.
+func newTestSigningDaemon(t *testing.T, daemonSigner Signer, tamper func(*types.DynamicFeeTx, *string)) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testAuthToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/accounts":
+			account, err := daemonSigner.NewAccount(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			json.NewEncoder(w).Encode(remoteAccountResponse{Address: account.Public})
+		case "/sign":
+			var req remoteSignRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			unsignedTx := new(types.Transaction)
+			if err := unsignedTx.UnmarshalBinary(req.Tx); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			txData := &types.DynamicFeeTx{
+				To:        unsignedTx.To(),
+				Nonce:     unsignedTx.Nonce(),
+				Data:      unsignedTx.Data(),
+				Gas:       unsignedTx.Gas(),
+				GasFeeCap: unsignedTx.GasFeeCap(),
+				GasTipCap: unsignedTx.GasTipCap(),
+				Value:     unsignedTx.Value(),
+			}
+			address := req.Address
+			if tamper != nil {
+				tamper(txData, &address)
+			}
+
+			signedTx, err := daemonSigner.SignTx(r.Context(), address, txData)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			signedTxBytes, err := signedTx.MarshalBinary()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			json.NewEncoder(w).Encode(remoteSignResponse{SignedTx: hexutil.Bytes(signedTxBytes)})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRemoteSigner(t *testing.T) {
+	ctx := context.Background()
+
+	otherPublicKey, otherPrivateKey := newTestSystemKey(t)
+	daemonSigner := newTestMemorySigner(t, map[string]string{otherPublicKey: otherPrivateKey})
+
+	tests := []struct {
+		name      string
+		authToken string
+		tamper    func(*types.DynamicFeeTx, *string)
+		wantErr   bool
+	}{
+		{
+			name:      "signs the requested tx",
+			authToken: testAuthToken,
+		},
+		{
+			name:      "rejected auth token",
+			authToken: "wrong-token",
+			wantErr:   true,
+		},
+		{
+			name:      "daemon signs a different tx",
+			authToken: testAuthToken,
+			tamper: func(txData *types.DynamicFeeTx, _ *string) {
+				txData.GasFeeCap = new(big.Int).Mul(txData.GasFeeCap, big.NewInt(2))
+			},
+			wantErr: true,
+		},
+		{
+			name:      "daemon signs as another account",
+			authToken: testAuthToken,
+			tamper: func(_ *types.DynamicFeeTx, address *string) {
+				*address = otherPublicKey
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			remoteSigner := NewRemoteSigner(RemoteSignerOpts{
+				AuthToken:   tt.authToken,
+				ChainSigner: testChainSigner,
+				Endpoint:    newTestSigningDaemon(t, daemonSigner, tt.tamper).URL + "/",
+				Timeout:     5 * time.Second,
+			})
+
+			account, err := remoteSigner.NewAccount(ctx)
+			if err != nil {
+				if tt.wantErr {
+					return
+				}
+				t.Fatal(err)
+			}
+
+			txData := newTestTxData(4)
+
+			tx, err := remoteSigner.SignTx(ctx, account.Public, txData)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				assertSignedBy(t, tx, txData, account.Public)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/grassrootseconomics/cic-custodial/internal/envelope"
+	"github.com/jackc/pgx/v5"
+)
+
+type (
+	ApiClient struct {
+		Id     uint
+		Name   string
+		Scopes []string
+		// Webhooks is set if a webhook secret is configured for the client.
+		Webhooks bool
+	}
+
+	sealedSecret struct {
+		secret     string
+		dataKey    *string
+		keyVersion *uint
+	}
+
+	plaintextSecret struct {
+		Id     uint   `db:"id"`
+		Secret string `db:"webhook_secret"`
+	}
+)
+
+// HasScope checks whether the API client was granted a particular scope.
+func (c ApiClient) HasScope(scope string) bool {
+	for _, v := range c.Scopes {
+		if v == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *PgStore) GetApiClient(
+	ctx context.Context,
+	keyHash string,
+) (ApiClient, error) {
+	var (
+		apiClient ApiClient
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.GetApiClient,
+		keyHash,
+	).Scan(
+		&apiClient.Id,
+		&apiClient.Name,
+		&apiClient.Scopes,
+		&apiClient.Webhooks,
+	); err != nil {
+		return apiClient, err
+	}
+
+	return apiClient, nil
+}
+
+// SetWebhookSecret sets the webhook secret of an API client by name, it reports false if there is no such client.
+// The secret is encrypted if a key provider is loaded, otherwise it is stored as plaintext.
+func (s *PgStore) SetWebhookSecret(
+	ctx context.Context,
+	clientName string,
+	secret string,
+) (bool, error) {
+	sealed, err := s.sealSecret(ctx, secret)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := s.db.Exec(
+		ctx,
+		s.queries.SetWebhookSecret,
+		clientName,
+		sealed.secret,
+		sealed.dataKey,
+		sealed.keyVersion,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// EncryptWebhookSecrets encrypts every legacy plaintext webhook secret in place and returns the number of secrets encrypted.
+func (s *PgStore) EncryptWebhookSecrets(
+	ctx context.Context,
+) (uint, error) {
+	var (
+		encrypted uint
+	)
+
+	if s.keyProvider == nil {
+		return 0, ErrNoKeyProvider
+	}
+
+	if err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var (
+			plaintextSecrets []plaintextSecret
+		)
+
+		if err := pgxscan.Select(
+			ctx,
+			tx,
+			&plaintextSecrets,
+			s.queries.GetPlaintextWebhookSecrets,
+		); err != nil {
+			return err
+		}
+
+		for _, secret := range plaintextSecrets {
+			sealed, err := s.sealSecret(ctx, secret.Secret)
+			if err != nil {
+				return err
+			}
+
+			if opened, err := s.openSecret(ctx, sealed); err != nil || opened != secret.Secret {
+				return fmt.Errorf("store: api client id %d: encrypted webhook secret does not match the plaintext secret", secret.Id)
+			}
+
+			if _, err := tx.Exec(
+				ctx,
+				s.queries.EncryptWebhookSecret,
+				secret.Id,
+				sealed.secret,
+				sealed.dataKey,
+				sealed.keyVersion,
+			); err != nil {
+				return err
+			}
+		}
+
+		encrypted = uint(len(plaintextSecrets))
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return encrypted, nil
+}
+
+// sealSecret encrypts a secret if a key provider is loaded, otherwise it is stored as plaintext.
+func (s *PgStore) sealSecret(ctx context.Context, secret string) (sealedSecret, error) {
+	if s.keyProvider == nil {
+		return sealedSecret{
+			secret: secret,
+		}, nil
+	}
+
+	sealed, err := envelope.Seal(ctx, s.keyProvider, []byte(secret), nil)
+	if err != nil {
+		return sealedSecret{}, err
+	}
+
+	dataKey := hexutil.Encode(sealed.DataKey)
+
+	return sealedSecret{
+		secret:     hexutil.Encode(sealed.Ciphertext),
+		dataKey:    &dataKey,
+		keyVersion: &sealed.KeyVersion,
+	}, nil
+}
+
+func (s *PgStore) openSecret(ctx context.Context, sealed sealedSecret) (string, error) {
+	if sealed.keyVersion == nil {
+		return sealed.secret, nil
+	}
+
+	if s.keyProvider == nil {
+		return "", ErrNoKeyProvider
+	}
+
+	ciphertext, err := hexutil.Decode(sealed.secret)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := hexutil.Decode(*sealed.dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := envelope.Open(ctx, s.keyProvider, envelope.Sealed{
+		Ciphertext: ciphertext,
+		DataKey:    dataKey,
+		KeyVersion: *sealed.keyVersion,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+type BatchTxStatus struct {
+	TrackingId     string  `db:"tracking_id" json:"trackingId"`
+	Status         string  `db:"status" json:"status"`
+	TransferValue  *string `db:"transfer_value" json:"transferValue"`
+	TxHash         *string `db:"tx_hash" json:"txHash"`
+	VoucherAddress *string `db:"voucher_address" json:"voucherAddress,omitempty"`
+	// FormattedValue and Symbol are set by the API from the voucher metadata.
+	FormattedValue string `db:"-" json:"formattedValue,omitempty"`
+	Symbol         string `db:"-" json:"symbol,omitempty"`
+}
+
+func (s *PgStore) CreateBatch(
+	ctx context.Context,
+	batchId string,
+	trackingIds []string,
+	clientId uint,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.CreateBatch,
+		batchId,
+		trackingIds,
+		clientId,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PgStore) DeleteBatchItems(
+	ctx context.Context,
+	batchId string,
+	trackingIds []string,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.DeleteBatchItems,
+		batchId,
+		trackingIds,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PgStore) GetBatchStatus(
+	ctx context.Context,
+	batchId string,
+) ([]BatchTxStatus, error) {
+	var (
+		txs []BatchTxStatus
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&txs,
+		s.queries.GetBatchStatus,
+		batchId,
+	); err != nil {
+		return nil, err
+	}
+
+	return txs, nil
+}
@@ -3,9 +3,51 @@ package store
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/celo-org/celo-blockchain/common/hexutil"
 	eth_crypto "github.com/celo-org/celo-blockchain/crypto"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/grassrootseconomics/cic-custodial/internal/envelope"
 	"github.com/grassrootseconomics/cic-custodial/pkg/keypair"
+	"github.com/jackc/pgx/v5"
+)
+
+const encryptKeystoreBatchSize = 100
+
+var (
+	ErrNoKeyProvider = errors.New("store: keystore encryption requires a key provider")
+	ErrKeyNotHeld    = errors.New("store: private key is held by a remote signer")
+	ErrKeyMismatch   = errors.New("store: private key does not belong to the public key")
+)
+
+type (
+	sealedKey struct {
+		privateKey string
+		dataKey    *string
+		keyVersion *uint
+		// bound is set once the ciphertext is sealed with the public key as additional data.
+		bound bool
+	}
+
+	// KeystoreAccount is a decrypted key along with its account state, it is used to move accounts between deployments.
+	KeystoreAccount struct {
+		PublicKey  string
+		PrivateKey *ecdsa.PrivateKey
+		Active     bool
+		GasLocked  bool
+	}
+
+	// unboundKey is a legacy plaintext key or a key sealed before it was bound to its public key.
+	unboundKey struct {
+		Id         uint    `db:"id"`
+		PublicKey  string  `db:"public_key"`
+		PrivateKey string  `db:"private_key"`
+		DataKey    *string `db:"data_key"`
+		KeyVersion *uint   `db:"key_version"`
+	}
 )
 
 func (s *PgStore) WriteKeyPair(
@@ -16,11 +58,19 @@ func (s *PgStore) WriteKeyPair(
 		id uint
 	)
 
+	sealed, err := s.sealPrivateKey(ctx, keypair.Public, keypair.Private)
+	if err != nil {
+		return id, err
+	}
+
 	if err := s.db.QueryRow(
 		ctx,
 		s.queries.WriteKeyPair,
 		keypair.Public,
-		keypair.Private,
+		sealed.privateKey,
+		sealed.dataKey,
+		sealed.keyVersion,
+		sealed.bound,
 	).Scan(&id); err != nil {
 		return id, err
 	}
@@ -33,21 +83,304 @@ func (s *PgStore) LoadPrivateKey(
 	publicKey string,
 ) (*ecdsa.PrivateKey, error) {
 	var (
-		privateKeyString string
+		sealed sealedKey
 	)
 
 	if err := s.db.QueryRow(
 		ctx,
 		s.queries.LoadKeyPair,
 		publicKey,
-	).Scan(&privateKeyString); err != nil {
+	).Scan(
+		&sealed.privateKey,
+		&sealed.dataKey,
+		&sealed.keyVersion,
+		&sealed.bound,
+	); err != nil {
 		return nil, err
 	}
 
-	privateKey, err := eth_crypto.HexToECDSA(privateKeyString)
+	return s.openPrivateKey(ctx, publicKey, sealed)
+}
+
+func (s *PgStore) GetKeystoreAccounts(
+	ctx context.Context,
+	publicKeys []string,
+) ([]KeystoreAccount, error) {
+	var (
+		accounts []KeystoreAccount
+	)
+
+	rows, err := s.db.Query(
+		ctx,
+		s.queries.GetKeystoreAccounts,
+		publicKeys,
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			account KeystoreAccount
+			sealed  sealedKey
+		)
+
+		if err := rows.Scan(
+			&account.PublicKey,
+			&sealed.privateKey,
+			&sealed.dataKey,
+			&sealed.keyVersion,
+			&sealed.bound,
+			&account.Active,
+			&account.GasLocked,
+		); err != nil {
+			return nil, err
+		}
+
+		account.PrivateKey, err = s.openPrivateKey(ctx, account.PublicKey, sealed)
+		if err != nil {
+			return nil, fmt.Errorf("store: account %s: %w", account.PublicKey, err)
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// ImportKeystoreAccount saves an account exported from another deployment.
+// It returns false without any changes if the public key already exists.
+func (s *PgStore) ImportKeystoreAccount(
+	ctx context.Context,
+	account KeystoreAccount,
+) (bool, error) {
+	var (
+		imported bool
+	)
+
+	sealed, err := s.sealPrivateKey(ctx, account.PublicKey, hexutil.Encode(eth_crypto.FromECDSA(account.PrivateKey))[2:])
+	if err != nil {
+		return false, err
+	}
+
+	if err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var (
+			id uint
+		)
+
+		if err := tx.QueryRow(
+			ctx,
+			s.queries.ImportKeyPair,
+			account.PublicKey,
+			sealed.privateKey,
+			sealed.dataKey,
+			sealed.keyVersion,
+			sealed.bound,
+			account.Active,
+		).Scan(&id); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		if _, err := tx.Exec(
+			ctx,
+			s.queries.SetGasLock,
+			id,
+			account.GasLocked,
+		); err != nil {
+			return err
+		}
+
+		imported = true
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	return imported, nil
+}
+
+// EncryptKeystore encrypts every legacy plaintext private key in place and returns the number of keys encrypted.
+// Keys encrypted before they were bound to their public key are re-encrypted with it.
+// Each key is decrypted back and compared before it is written.
+func (s *PgStore) EncryptKeystore(
+	ctx context.Context,
+) (uint, error) {
+	var (
+		encrypted uint
+	)
+
+	if s.keyProvider == nil {
+		return 0, ErrNoKeyProvider
+	}
+
+	for {
+		var (
+			unboundKeys []unboundKey
+		)
+
+		if err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+			if err := pgxscan.Select(
+				ctx,
+				tx,
+				&unboundKeys,
+				s.queries.GetUnboundKeys,
+				encryptKeystoreBatchSize,
+			); err != nil {
+				return err
+			}
+
+			for _, key := range unboundKeys {
+				privateKey, err := s.openPrivateKey(ctx, key.PublicKey, sealedKey{
+					privateKey: key.PrivateKey,
+					dataKey:    key.DataKey,
+					keyVersion: key.KeyVersion,
+				})
+				if err != nil {
+					return fmt.Errorf("store: keystore id %d: %w", key.Id, err)
+				}
+
+				privateKeyHex := hexutil.Encode(eth_crypto.FromECDSA(privateKey))[2:]
+
+				sealed, err := s.sealPrivateKey(ctx, key.PublicKey, privateKeyHex)
+				if err != nil {
+					return err
+				}
+
+				if err := s.verifySealedKey(ctx, key.PublicKey, privateKeyHex, sealed); err != nil {
+					return fmt.Errorf("store: keystore id %d: %w", key.Id, err)
+				}
+
+				if _, err := tx.Exec(
+					ctx,
+					s.queries.EncryptKey,
+					key.Id,
+					sealed.privateKey,
+					sealed.dataKey,
+					sealed.keyVersion,
+				); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}); err != nil {
+			return encrypted, err
+		}
+
+		encrypted += uint(len(unboundKeys))
+
+		if len(unboundKeys) < encryptKeystoreBatchSize {
+			return encrypted, nil
+		}
+	}
+}
+
+// sealPrivateKey encrypts a hex private key if a key provider is loaded, otherwise it is stored as plaintext.
+// The ciphertext is bound to the public key so that it can't be copied onto another keystore row and opened as that account's key.
+func (s *PgStore) sealPrivateKey(ctx context.Context, publicKey string, privateKey string) (sealedKey, error) {
+	if s.keyProvider == nil || privateKey == "" {
+		return sealedKey{
+			privateKey: privateKey,
+		}, nil
+	}
+
+	privateKeyBytes, err := hexutil.Decode("0x" + privateKey)
+	if err != nil {
+		return sealedKey{}, err
+	}
+
+	sealed, err := envelope.Seal(ctx, s.keyProvider, privateKeyBytes, []byte(publicKey))
+	if err != nil {
+		return sealedKey{}, err
+	}
+
+	dataKey := hexutil.Encode(sealed.DataKey)
+
+	return sealedKey{
+		privateKey: hexutil.Encode(sealed.Ciphertext),
+		dataKey:    &dataKey,
+		keyVersion: &sealed.KeyVersion,
+		bound:      true,
+	}, nil
+}
+
+// openPrivateKey decrypts the private key of a keystore row and checks that it belongs to the row's public key.
+func (s *PgStore) openPrivateKey(ctx context.Context, publicKey string, sealed sealedKey) (*ecdsa.PrivateKey, error) {
+	if sealed.privateKey == "" {
+		return nil, ErrKeyNotHeld
+	}
+
+	var (
+		privateKey *ecdsa.PrivateKey
+		err        error
+	)
+
+	if sealed.keyVersion == nil {
+		privateKey, err = eth_crypto.HexToECDSA(sealed.privateKey)
+	} else {
+		privateKey, err = s.openSealedPrivateKey(ctx, publicKey, sealed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Legacy plaintext and unbound keys are not bound to the row, a key moved onto another row is caught here instead.
+	if !strings.EqualFold(eth_crypto.PubkeyToAddress(privateKey.PublicKey).Hex(), publicKey) {
+		return nil, ErrKeyMismatch
+	}
 
 	return privateKey, nil
 }
+
+func (s *PgStore) openSealedPrivateKey(ctx context.Context, publicKey string, sealed sealedKey) (*ecdsa.PrivateKey, error) {
+	if s.keyProvider == nil {
+		return nil, ErrNoKeyProvider
+	}
+
+	ciphertext, err := hexutil.Decode(sealed.privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := hexutil.Decode(*sealed.dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var additionalData []byte
+	if sealed.bound {
+		additionalData = []byte(publicKey)
+	}
+
+	privateKeyBytes, err := envelope.Open(ctx, s.keyProvider, envelope.Sealed{
+		Ciphertext: ciphertext,
+		DataKey:    dataKey,
+		KeyVersion: *sealed.keyVersion,
+	}, additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	return eth_crypto.ToECDSA(privateKeyBytes)
+}
+
+func (s *PgStore) verifySealedKey(ctx context.Context, publicKey string, privateKey string, sealed sealedKey) error {
+	openedKey, err := s.openPrivateKey(ctx, publicKey, sealed)
+	if err != nil {
+		return err
+	}
+
+	if hexutil.Encode(eth_crypto.FromECDSA(openedKey))[2:] != strings.ToLower(privateKey) {
+		return errors.New("encrypted key does not match the plaintext key")
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	eth_crypto "github.com/celo-org/celo-blockchain/crypto"
+	"github.com/grassrootseconomics/cic-custodial/internal/envelope"
+	"github.com/grassrootseconomics/cic-custodial/pkg/keypair"
+)
+
+func newTestKeystore(t *testing.T) *PgStore {
+	t.Helper()
+
+	masterKey := make([]byte, 32)
+	if _, err := rand.Read(masterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	keyProvider, err := envelope.NewLocalKeyProvider(envelope.LocalKeyProviderOpts{
+		MasterKeys: map[uint][]byte{1: masterKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &PgStore{
+		keyProvider: keyProvider,
+	}
+}
+
+func newTestKey(t *testing.T) keypair.Key {
+	t.Helper()
+
+	key, err := keypair.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// sealUnbound seals a key the way it was sealed before keys were bound to their public key.
+func sealUnbound(t *testing.T, s *PgStore, privateKey string) sealedKey {
+	t.Helper()
+
+	privateKeyBytes, err := hexutil.Decode("0x" + privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := envelope.Seal(context.Background(), s.keyProvider, privateKeyBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataKey := hexutil.Encode(sealed.DataKey)
+
+	return sealedKey{
+		privateKey: hexutil.Encode(sealed.Ciphertext),
+		dataKey:    &dataKey,
+		keyVersion: &sealed.KeyVersion,
+	}
+}
+
+func TestSealPrivateKey(t *testing.T) {
+	ctx := context.Background()
+	s := newTestKeystore(t)
+	key := newTestKey(t)
+
+	sealed, err := s.sealPrivateKey(ctx, key.Public, key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !sealed.bound {
+		t.Fatal("sealed key not bound to its public key")
+	}
+
+	if err := s.verifySealedKey(ctx, key.Public, key.Private, sealed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenSwappedPrivateKey(t *testing.T) {
+	ctx := context.Background()
+	s := newTestKeystore(t)
+	key, otherKey := newTestKey(t), newTestKey(t)
+
+	bound, err := s.sealPrivateKey(ctx, key.Public, key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		sealed    sealedKey
+		wantErrIs error
+	}{
+		// The ciphertext fails to open with the other public key as additional data.
+		{name: "bound", sealed: bound},
+		{name: "unbound", sealed: sealUnbound(t, s, key.Private), wantErrIs: ErrKeyMismatch},
+		{name: "plaintext", sealed: sealedKey{privateKey: key.Private}, wantErrIs: ErrKeyMismatch},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			privateKey, err := s.openPrivateKey(ctx, key.Public, tt.sealed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := hexutil.Encode(eth_crypto.FromECDSA(privateKey))[2:]; got != key.Private {
+				t.Fatalf("got private key %s, want %s", got, key.Private)
+			}
+
+			// The same row values copied onto the row of another account.
+			if _, err := s.openPrivateKey(ctx, otherKey.Public, tt.sealed); err == nil {
+				t.Fatal("private key opened on the row of another account")
+			} else if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
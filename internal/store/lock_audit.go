@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+type LockAudit struct {
+	Scope     string     `db:"scope" json:"scope"`
+	Locked    bool       `db:"locked" json:"locked"`
+	Reason    string     `db:"reason" json:"reason"`
+	SetBy     string     `db:"set_by" json:"setBy"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expiresAt"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+}
+
+func (s *PgStore) CreateLockAudit(
+	ctx context.Context,
+	lockAudit LockAudit,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.CreateLockAudit,
+		lockAudit.Scope,
+		lockAudit.Locked,
+		lockAudit.Reason,
+		lockAudit.SetBy,
+		lockAudit.ExpiresAt,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PgStore) GetLatestLockAudits(
+	ctx context.Context,
+) ([]LockAudit, error) {
+	var (
+		lockAudits []LockAudit
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&lockAudits,
+		s.queries.GetLatestLockAudits,
+	); err != nil {
+		return nil, err
+	}
+
+	return lockAudits, nil
+}
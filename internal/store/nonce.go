@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *PgStore) PeekNonce(
+	ctx context.Context,
+	publicKey string,
+) (uint64, error) {
+	var (
+		nonce uint64
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.PeekNonce,
+		publicKey,
+	).Scan(&nonce); err != nil {
+		return 0, err
+	}
+
+	return nonce, nil
+}
+
+// AcquireNonce returns the next nonce and increments it.
+// The row is locked for the duration of the transaction so that concurrent callers are serialized.
+func (s *PgStore) AcquireNonce(
+	ctx context.Context,
+	publicKey string,
+) (uint64, error) {
+	var (
+		nonce uint64
+	)
+
+	if err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(
+			ctx,
+			s.queries.LockNonce,
+			publicKey,
+		).Scan(&nonce); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(
+			ctx,
+			s.queries.SetNonce,
+			publicKey,
+			nonce+1,
+		)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return nonce, nil
+}
+
+// ReturnNonce decrements the next nonce, it never goes below 0.
+func (s *PgStore) ReturnNonce(
+	ctx context.Context,
+	publicKey string,
+) error {
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var (
+			nonce uint64
+		)
+
+		if err := tx.QueryRow(
+			ctx,
+			s.queries.LockNonce,
+			publicKey,
+		).Scan(&nonce); err != nil {
+			return err
+		}
+
+		if nonce == 0 {
+			return nil
+		}
+
+		_, err := tx.Exec(
+			ctx,
+			s.queries.SetNonce,
+			publicKey,
+			nonce-1,
+		)
+		return err
+	})
+}
+
+// InitNonce sets the next nonce only if the account has none, a concurrent init always wins.
+func (s *PgStore) InitNonce(
+	ctx context.Context,
+	publicKey string,
+	nonce uint64,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.InitNonce,
+		publicKey,
+		nonce,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PgStore) SetNonce(
+	ctx context.Context,
+	publicKey string,
+	nonce uint64,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.SetNonce,
+		publicKey,
+		nonce,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
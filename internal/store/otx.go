@@ -2,15 +2,18 @@ package store
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"time"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
+	"github.com/jackc/pgx/v5"
 )
 
 type (
 	Otx struct {
+		Id            uint
 		TrackingId    string
 		Type          enum.OtxType
 		RawTx         string
@@ -18,16 +21,46 @@ type (
 		From          string
 		Data          string
 		GasLimit      uint64
-		TransferValue uint64
+		TransferValue *big.Int
 		GasPrice      *big.Int
 		Nonce         uint64
+		ClientId      uint
+		Replaces      uint
+		// VoucherAddress is only set on TRANSFER_VOUCHER and TRANSFER_AUTHORIZATION otx's, the latter carry the approved amount as TransferValue.
+		VoucherAddress string
+		CreatedAt      time.Time
+	}
+	// OtxSender is the signed tx and recorded sender of an otx.
+	OtxSender struct {
+		Id    uint   `db:"id"`
+		RawTx string `db:"raw_tx"`
+		From  string `db:"from"`
 	}
 	TxStatus struct {
+		Id            uint      `db:"id" json:"id"`
+		Block         *uint64   `db:"block" json:"block"`
 		CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+		From          string    `db:"from" json:"from"`
+		Nonce         uint64    `db:"nonce" json:"nonce"`
 		Status        string    `db:"status" json:"status"`
-		TransferValue uint64    `db:"transfer_value" json:"transferValue"`
+		TransferValue string    `db:"transfer_value" json:"transferValue"`
 		TxHash        string    `db:"tx_hash" json:"txHash"`
 		Type          string    `db:"type" json:"txType"`
+		TrackingId    string    `db:"tracking_id" json:"trackingId"`
+		// VoucherAddress is only recorded for voucher transfers and approvals.
+		VoucherAddress *string `db:"voucher_address" json:"voucherAddress,omitempty"`
+		// FormattedValue and Symbol are set by the API from the voucher metadata.
+		FormattedValue string `db:"-" json:"formattedValue,omitempty"`
+		Symbol         string `db:"-" json:"symbol,omitempty"`
+	}
+	TxHistoryFilter struct {
+		From   string
+		Type   string
+		Status string
+		Since  *time.Time
+		Until  *time.Time
+		Cursor uint
+		Limit  uint
 	}
 )
 
@@ -37,8 +70,14 @@ func (s *PgStore) CreateOtx(
 ) (uint, error) {
 	var (
 		id uint
+		// Otx's which do not move vouchers (e.g. registrations) leave the transfer value unset.
+		transferValue = "0"
 	)
 
+	if otx.TransferValue != nil {
+		transferValue = otx.TransferValue.String()
+	}
+
 	if err := s.db.QueryRow(
 		ctx,
 		s.queries.CreateOTX,
@@ -50,8 +89,11 @@ func (s *PgStore) CreateOtx(
 		otx.Data,
 		otx.GasPrice,
 		otx.GasLimit,
-		otx.TransferValue,
+		transferValue,
 		otx.Nonce,
+		otx.ClientId,
+		otx.Replaces,
+		otx.VoucherAddress,
 	).Scan(
 		&id,
 	); err != nil {
@@ -61,6 +103,109 @@ func (s *PgStore) CreateOtx(
 	return id, nil
 }
 
+func (s *PgStore) GetOtx(
+	ctx context.Context,
+	otxId uint,
+) (Otx, enum.OtxStatus, error) {
+	var (
+		otx           Otx
+		status        enum.OtxStatus
+		transferValue string
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.GetOtx,
+		otxId,
+	).Scan(
+		&otx.Id,
+		&otx.TrackingId,
+		&otx.Type,
+		&otx.RawTx,
+		&otx.TxHash,
+		&otx.From,
+		&transferValue,
+		&otx.Nonce,
+		&otx.ClientId,
+		&otx.VoucherAddress,
+		&otx.CreatedAt,
+		&status,
+	); err != nil {
+		return otx, status, err
+	}
+
+	parsedTransferValue, err := parseNumeric(transferValue)
+	if err != nil {
+		return otx, status, err
+	}
+	otx.TransferValue = parsedTransferValue
+
+	return otx, status, nil
+}
+
+func (s *PgStore) GetStuckOtx(
+	ctx context.Context,
+	stuckAfter time.Duration,
+	limit int,
+) ([]uint, error) {
+	var (
+		otxIds []uint
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&otxIds,
+		s.queries.GetStuckOtx,
+		stuckAfter,
+		limit,
+	); err != nil {
+		return nil, err
+	}
+
+	return otxIds, nil
+}
+
+func (s *PgStore) GetApprovalOtx(
+	ctx context.Context,
+	afterId uint,
+	limit int,
+) ([]OtxSender, error) {
+	var (
+		otxSenders []OtxSender
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&otxSenders,
+		s.queries.GetApprovalOtx,
+		afterId,
+		limit,
+	); err != nil {
+		return nil, err
+	}
+
+	return otxSenders, nil
+}
+
+func (s *PgStore) SetOtxSender(
+	ctx context.Context,
+	otxId uint,
+	from string,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.SetOtxSender,
+		otxId,
+		from,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *PgStore) GetNextNonce(
 	ctx context.Context,
 	publicAddress string,
@@ -82,31 +227,73 @@ func (s *PgStore) GetNextNonce(
 	return lastNonce, nil
 }
 
+func (s *PgStore) GetNextDispatchedNonce(
+	ctx context.Context,
+	publicAddress string,
+) (uint64, error) {
+	var (
+		nextNonce uint64
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.GetNextDispatchedNonce,
+		publicAddress,
+	).Scan(
+		&nextNonce,
+	); err != nil {
+		return 0, err
+	}
+
+	return nextNonce, nil
+}
+
 func (s *PgStore) GetTxStatus(
 	ctx context.Context,
 	trackingId string,
-) (TxStatus, error) {
+) ([]TxStatus, error) {
 	var (
-		tx TxStatus
+		txs []TxStatus
 	)
 
-	rows, err := s.db.Query(
+	if err := pgxscan.Select(
 		ctx,
+		s.db,
+		&txs,
 		s.queries.GetTxStatusByTrackingId,
 		trackingId,
-	)
-	if err != nil {
-		return tx, err
+	); err != nil {
+		return nil, err
 	}
 
-	if err := pgxscan.ScanOne(
-		&tx,
-		rows,
+	return txs, nil
+}
+
+func (s *PgStore) GetAccountTransactions(
+	ctx context.Context,
+	filter TxHistoryFilter,
+) ([]TxStatus, error) {
+	var (
+		txs []TxStatus
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&txs,
+		s.queries.GetAccountTransactions,
+		filter.From,
+		filter.Type,
+		filter.Status,
+		filter.Since,
+		filter.Until,
+		filter.Cursor,
+		filter.Limit,
 	); err != nil {
-		return tx, err
+		return nil, err
 	}
 
-	return tx, nil
+	return txs, nil
 }
 
 func (s *PgStore) CreateDispatchStatus(
@@ -131,8 +318,9 @@ func (s *PgStore) UpdateDispatchStatus(
 	txSuccess bool,
 	txHash string,
 	txBlock uint64,
-) error {
+) (Otx, bool, error) {
 	var (
+		otx    Otx
 		status = enum.SUCCESS
 	)
 
@@ -140,15 +328,125 @@ func (s *PgStore) UpdateDispatchStatus(
 		status = enum.REVERTED
 	}
 
-	if _, err := s.db.Exec(
+	if err := s.db.QueryRow(
 		ctx,
 		s.queries.UpdateDispatchStatus,
 		txHash,
 		status,
 		txBlock,
+	).Scan(
+		&otx.Id,
+		&otx.TrackingId,
+		&otx.Type,
+		&otx.TxHash,
+		&otx.From,
+		&otx.Nonce,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return otx, false, nil
+		}
+
+		return otx, false, err
+	}
+
+	return otx, true, nil
+}
+
+// GetMinedOtx returns a mined otx and its mine status, it reports false if the tx is not ours or not yet mined.
+func (s *PgStore) GetMinedOtx(
+	ctx context.Context,
+	txHash string,
+) (Otx, enum.OtxStatus, bool, error) {
+	var (
+		otx    Otx
+		status enum.OtxStatus
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.GetMinedOtx,
+		txHash,
+	).Scan(
+		&otx.Id,
+		&otx.TrackingId,
+		&otx.Type,
+		&otx.TxHash,
+		&otx.From,
+		&otx.Nonce,
+		&status,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return otx, status, false, nil
+		}
+
+		return otx, status, false, err
+	}
+
+	return otx, status, true, nil
+}
+
+func (s *PgStore) MarkOtxObsolete(
+	ctx context.Context,
+	otxId uint,
+) error {
+	return s.SetDispatchStatus(ctx, otxId, enum.OBSOLETE)
+}
+
+func (s *PgStore) SetDispatchStatus(
+	ctx context.Context,
+	otxId uint,
+	status enum.OtxStatus,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.SetDispatchStatus,
+		otxId,
+		status,
 	); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (s *PgStore) GetOtxIdByNonce(
+	ctx context.Context,
+	publicKey string,
+	nonce uint64,
+) (uint, error) {
+	var (
+		otxId uint
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.GetOtxIdByNonce,
+		publicKey,
+		nonce,
+	).Scan(&otxId); err != nil {
+		return 0, err
+	}
+
+	return otxId, nil
+}
+
+func (s *PgStore) GetActiveAccounts(
+	ctx context.Context,
+	window time.Duration,
+) ([]string, error) {
+	var (
+		accounts []string
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&accounts,
+		s.queries.GetActiveAccounts,
+		window,
+	); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
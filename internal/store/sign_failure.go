@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
+)
+
+type SignFailure struct {
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	From      string    `db:"from" json:"from"`
+	Reason    string    `db:"reason" json:"reason"`
+	Type      string    `db:"type" json:"txType"`
+}
+
+func (s *PgStore) CreateSignFailure(
+	ctx context.Context,
+	trackingId string,
+	otxType enum.OtxType,
+	from string,
+	reason string,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.CreateSignFailure,
+		trackingId,
+		otxType,
+		from,
+		reason,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PgStore) GetSignFailures(
+	ctx context.Context,
+	trackingId string,
+) ([]SignFailure, error) {
+	var (
+		failures []SignFailure
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&failures,
+		s.queries.GetSignFailures,
+		trackingId,
+	); err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
+	"math/big"
 	"os"
+	"time"
 
+	"github.com/grassrootseconomics/cic-custodial/internal/envelope"
 	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
 	"github.com/grassrootseconomics/cic-custodial/pkg/keypair"
 	"github.com/grassrootseconomics/cic-custodial/pkg/util"
@@ -19,46 +22,139 @@ type (
 		// Keypair related actions.
 		LoadPrivateKey(context.Context, string) (*ecdsa.PrivateKey, error)
 		WriteKeyPair(context.Context, keypair.Key) (uint, error)
+		EncryptKeystore(context.Context) (uint, error)
+		GetKeystoreAccounts(context.Context, []string) ([]KeystoreAccount, error)
+		ImportKeystoreAccount(context.Context, KeystoreAccount) (bool, error)
 		// Otx related actions.
 		CreateOtx(context.Context, Otx) (uint, error)
+		GetOtx(context.Context, uint) (Otx, enum.OtxStatus, error)
+		GetStuckOtx(context.Context, time.Duration, int) ([]uint, error)
+		MarkOtxObsolete(context.Context, uint) error
+		SetDispatchStatus(context.Context, uint, enum.OtxStatus) error
+		GetOtxIdByNonce(context.Context, string, uint64) (uint, error)
+		GetActiveAccounts(context.Context, time.Duration) ([]string, error)
 		GetNextNonce(context.Context, string) (uint64, error)
-		GetTxStatus(context.Context, string) (TxStatus, error)
+		GetNextDispatchedNonce(context.Context, string) (uint64, error)
+		GetTxStatus(context.Context, string) ([]TxStatus, error)
+		GetAccountTransactions(context.Context, TxHistoryFilter) ([]TxStatus, error)
 		CreateDispatchStatus(context.Context, uint, enum.OtxStatus) error
-		UpdateDispatchStatus(context.Context, bool, string, uint64) error
+		UpdateDispatchStatus(context.Context, bool, string, uint64) (Otx, bool, error)
+		GetMinedOtx(context.Context, string) (Otx, enum.OtxStatus, bool, error)
+		CreateSignFailure(context.Context, string, enum.OtxType, string, string) error
+		GetSignFailures(context.Context, string) ([]SignFailure, error)
+		GetApprovalOtx(context.Context, uint, int) ([]OtxSender, error)
+		SetOtxSender(context.Context, uint, string) error
 		// Account related actions.
 		ActivateAccount(context.Context, string) error
 		GetAccountStatus(context.Context, string) (bool, bool, error)
 		// Gas quota related actions.
 		GasLock(context.Context, string) error
 		GasUnlock(context.Context, string) error
+		// API client related actions.
+		GetApiClient(context.Context, string) (ApiClient, error)
+		SetWebhookSecret(context.Context, string, string) (bool, error)
+		EncryptWebhookSecrets(context.Context) (uint, error)
+		// Batch related actions.
+		CreateBatch(context.Context, string, []string, uint) error
+		DeleteBatchItems(context.Context, string, []string) error
+		GetBatchStatus(context.Context, string) ([]BatchTxStatus, error)
+		// Nonce related actions.
+		PeekNonce(context.Context, string) (uint64, error)
+		AcquireNonce(context.Context, string) (uint64, error)
+		ReturnNonce(context.Context, string) error
+		InitNonce(context.Context, string, uint64) error
+		SetNonce(context.Context, string, uint64) error
+		// System lock related actions.
+		CreateLockAudit(context.Context, LockAudit) error
+		GetLatestLockAudits(context.Context) ([]LockAudit, error)
+		// Webhook related actions.
+		CreateWebhook(context.Context, string, string, uint) error
+		GetWebhook(context.Context, string) (Webhook, error)
+		CreateWebhookDelivery(context.Context, WebhookDelivery) error
+		GetWebhookDeliveries(context.Context, string) ([]WebhookDelivery, error)
+		// Transfer policy related actions.
+		GetTransferPolicy(context.Context, string, string) (TransferPolicy, error)
+		GetTransferUsage(context.Context, string, string) (TransferUsage, error)
+		// Voucher allowlist related actions.
+		AddVoucher(context.Context, Voucher) (bool, error)
+		RemoveVoucher(context.Context, string) (bool, error)
+		GetVouchers(context.Context) ([]Voucher, error)
 	}
 
 	Opts struct {
 		DSN                  string
+		KeyProvider          envelope.KeyProvider
 		MigrationsFolderPath string
 		QueriesFolderPath    string
 	}
 
 	PgStore struct {
-		db      *pgxpool.Pool
-		queries *queries
+		db          *pgxpool.Pool
+		keyProvider envelope.KeyProvider
+		queries     *queries
 	}
 
 	queries struct {
 		// Keystore related queries.
-		WriteKeyPair string `query:"write-key-pair"`
-		LoadKeyPair  string `query:"load-key-pair"`
+		WriteKeyPair        string `query:"write-key-pair"`
+		LoadKeyPair         string `query:"load-key-pair"`
+		GetKeystoreAccounts string `query:"get-keystore-accounts"`
+		ImportKeyPair       string `query:"import-key-pair"`
+		SetGasLock          string `query:"set-gas-lock"`
+		GetUnboundKeys      string `query:"get-unbound-keys"`
+		EncryptKey          string `query:"encrypt-key"`
 		// Otx related queries.
 		CreateOTX               string `query:"create-otx"`
+		GetOtx                  string `query:"get-otx"`
+		GetStuckOtx             string `query:"get-stuck-otx"`
+		SetDispatchStatus       string `query:"set-dispatch-status"`
+		GetOtxIdByNonce         string `query:"get-otx-id-by-nonce"`
+		GetActiveAccounts       string `query:"get-active-accounts"`
 		GetNextNonce            string `query:"get-next-nonce"`
+		GetNextDispatchedNonce  string `query:"get-next-dispatched-nonce"`
 		GetTxStatusByTrackingId string `query:"get-tx-status-by-tracking-id"`
+		GetAccountTransactions  string `query:"get-account-transactions"`
 		CreateDispatchStatus    string `query:"create-dispatch-status"`
 		UpdateDispatchStatus    string `query:"update-dispatch-status"`
+		GetMinedOtx             string `query:"get-mined-otx"`
+		CreateSignFailure       string `query:"create-sign-failure"`
+		GetSignFailures         string `query:"get-sign-failures-by-tracking-id"`
+		GetApprovalOtx          string `query:"get-approval-otx"`
+		SetOtxSender            string `query:"set-otx-sender"`
 		// Account related queries.
 		ActivateAccount  string `query:"activate-account"`
 		GetAccountStatus string `query:"get-account-status-by-address"`
 		GasLock          string `query:"acc-gas-lock"`
 		GasUnlock        string `query:"acc-gas-unlock"`
+		// API client related queries.
+		GetApiClient               string `query:"get-api-client-by-key-hash"`
+		SetWebhookSecret           string `query:"set-webhook-secret"`
+		GetPlaintextWebhookSecrets string `query:"get-plaintext-webhook-secrets"`
+		EncryptWebhookSecret       string `query:"encrypt-webhook-secret"`
+		// Batch related queries.
+		CreateBatch      string `query:"create-batch"`
+		DeleteBatchItems string `query:"delete-batch-items"`
+		GetBatchStatus   string `query:"get-batch-status"`
+		// Nonce related queries.
+		PeekNonce string `query:"peek-nonce"`
+		LockNonce string `query:"lock-nonce"`
+		InitNonce string `query:"init-nonce"`
+		SetNonce  string `query:"set-nonce"`
+		// System lock related queries.
+		CreateLockAudit     string `query:"create-lock-audit"`
+		GetLatestLockAudits string `query:"get-latest-lock-audits"`
+		// Webhook related queries.
+		CreateWebhook         string `query:"create-webhook"`
+		GetWebhook            string `query:"get-webhook"`
+		CreateWebhookDelivery string `query:"create-webhook-delivery"`
+		GetWebhookDeliveries  string `query:"get-webhook-deliveries-by-tracking-id"`
+		// Transfer policy related queries.
+		GetTransferPolicy string `query:"get-transfer-policy"`
+		GetTransferUsage  string `query:"get-transfer-usage"`
+		// Voucher allowlist related queries.
+		AddVoucher    string `query:"add-voucher"`
+		RemoveVoucher string `query:"remove-voucher"`
+		GetVouchers   string `query:"get-vouchers"`
 	}
 )
 
@@ -83,8 +179,9 @@ func NewPgStore(o Opts) (Store, error) {
 	}
 
 	return &PgStore{
-		db:      dbPool,
-		queries: queries,
+		db:          dbPool,
+		keyProvider: o.KeyProvider,
+		queries:     queries,
 	}, nil
 }
 
@@ -128,3 +225,13 @@ func runMigrations(ctx context.Context, dbPool *pgxpool.Pool, migrationsPath str
 
 	return nil
 }
+
+// parseNumeric parses a NUMERIC(78, 0) column selected as text.
+func parseNumeric(value string) (*big.Int, error) {
+	parsedValue, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("store: invalid numeric value %q", value)
+	}
+
+	return parsedValue, nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"math/big"
+)
+
+type (
+	TransferPolicy struct {
+		Id                  uint
+		PublicKey           *string
+		VoucherAddress      *string
+		MaxPerTransfer      *big.Int
+		MaxPerDay           *big.Int
+		MaxTransfersPerHour *uint64
+	}
+
+	TransferUsage struct {
+		DayValue  *big.Int
+		HourCount uint64
+	}
+)
+
+func (s *PgStore) GetTransferPolicy(
+	ctx context.Context,
+	publicKey string,
+	voucherAddress string,
+) (TransferPolicy, error) {
+	var (
+		transferPolicy TransferPolicy
+		maxPerTransfer *string
+		maxPerDay      *string
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.GetTransferPolicy,
+		publicKey,
+		voucherAddress,
+	).Scan(
+		&transferPolicy.Id,
+		&transferPolicy.PublicKey,
+		&transferPolicy.VoucherAddress,
+		&maxPerTransfer,
+		&maxPerDay,
+		&transferPolicy.MaxTransfersPerHour,
+	); err != nil {
+		return transferPolicy, err
+	}
+
+	if maxPerTransfer != nil {
+		parsedMaxPerTransfer, err := parseNumeric(*maxPerTransfer)
+		if err != nil {
+			return transferPolicy, err
+		}
+		transferPolicy.MaxPerTransfer = parsedMaxPerTransfer
+	}
+
+	if maxPerDay != nil {
+		parsedMaxPerDay, err := parseNumeric(*maxPerDay)
+		if err != nil {
+			return transferPolicy, err
+		}
+		transferPolicy.MaxPerDay = parsedMaxPerDay
+	}
+
+	return transferPolicy, nil
+}
+
+func (s *PgStore) GetTransferUsage(
+	ctx context.Context,
+	publicKey string,
+	voucherAddress string,
+) (TransferUsage, error) {
+	var (
+		transferUsage TransferUsage
+		dayValue      string
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.GetTransferUsage,
+		publicKey,
+		voucherAddress,
+	).Scan(
+		&dayValue,
+		&transferUsage.HourCount,
+	); err != nil {
+		return transferUsage, err
+	}
+
+	parsedDayValue, err := parseNumeric(dayValue)
+	if err != nil {
+		return transferUsage, err
+	}
+	transferUsage.DayValue = parsedDayValue
+
+	return transferUsage, nil
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+type Voucher struct {
+	VoucherAddress string    `db:"voucher_address" json:"voucherAddress"`
+	Reason         string    `db:"reason" json:"reason"`
+	AddedBy        string    `db:"added_by" json:"addedBy"`
+	CreatedAt      time.Time `db:"created_at" json:"createdAt"`
+}
+
+// AddVoucher reports whether the voucher was not already allowlisted.
+func (s *PgStore) AddVoucher(
+	ctx context.Context,
+	voucher Voucher,
+) (bool, error) {
+	commandTag, err := s.db.Exec(
+		ctx,
+		s.queries.AddVoucher,
+		voucher.VoucherAddress,
+		voucher.Reason,
+		voucher.AddedBy,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return commandTag.RowsAffected() > 0, nil
+}
+
+// RemoveVoucher reports whether the voucher was manually allowlisted.
+func (s *PgStore) RemoveVoucher(
+	ctx context.Context,
+	voucherAddress string,
+) (bool, error) {
+	commandTag, err := s.db.Exec(
+		ctx,
+		s.queries.RemoveVoucher,
+		voucherAddress,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return commandTag.RowsAffected() > 0, nil
+}
+
+func (s *PgStore) GetVouchers(
+	ctx context.Context,
+) ([]Voucher, error) {
+	var (
+		vouchers []Voucher
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&vouchers,
+		s.queries.GetVouchers,
+	); err != nil {
+		return nil, err
+	}
+
+	return vouchers, nil
+}
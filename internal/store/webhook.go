@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+type (
+	Webhook struct {
+		Id          uint
+		CallbackUrl string
+		Secret      string
+	}
+
+	WebhookDelivery struct {
+		WebhookId  uint      `db:"-" json:"-"`
+		Event      string    `db:"event" json:"event"`
+		TxHash     string    `db:"tx_hash" json:"txHash"`
+		Attempt    uint      `db:"attempt" json:"attempt"`
+		StatusCode *int      `db:"status_code" json:"statusCode"`
+		Error      string    `db:"error" json:"error"`
+		CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+	}
+)
+
+func (s *PgStore) CreateWebhook(
+	ctx context.Context,
+	trackingId string,
+	callbackUrl string,
+	clientId uint,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.CreateWebhook,
+		trackingId,
+		callbackUrl,
+		clientId,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PgStore) GetWebhook(
+	ctx context.Context,
+	trackingId string,
+) (Webhook, error) {
+	var (
+		webhook Webhook
+		sealed  sealedSecret
+	)
+
+	if err := s.db.QueryRow(
+		ctx,
+		s.queries.GetWebhook,
+		trackingId,
+	).Scan(
+		&webhook.Id,
+		&webhook.CallbackUrl,
+		&sealed.secret,
+		&sealed.dataKey,
+		&sealed.keyVersion,
+	); err != nil {
+		return webhook, err
+	}
+
+	secret, err := s.openSecret(ctx, sealed)
+	if err != nil {
+		return webhook, err
+	}
+	webhook.Secret = secret
+
+	return webhook, nil
+}
+
+func (s *PgStore) CreateWebhookDelivery(
+	ctx context.Context,
+	delivery WebhookDelivery,
+) error {
+	if _, err := s.db.Exec(
+		ctx,
+		s.queries.CreateWebhookDelivery,
+		delivery.WebhookId,
+		delivery.Event,
+		delivery.TxHash,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Error,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PgStore) GetWebhookDeliveries(
+	ctx context.Context,
+	trackingId string,
+) ([]WebhookDelivery, error) {
+	var (
+		deliveries []WebhookDelivery
+	)
+
+	if err := pgxscan.Select(
+		ctx,
+		s.db,
+		&deliveries,
+		s.queries.GetWebhookDeliveries,
+		trackingId,
+	); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -3,20 +3,24 @@ package sub
 import (
 	"context"
 	"encoding/json"
+	"math/big"
 
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/tasker/task"
+	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
 	"github.com/nats-io/nats.go"
 )
 
 type (
 	ChainEvent struct {
-		Block           uint64 `json:"block"`
-		From            string `json:"from"`
-		To              string `json:"to"`
-		ContractAddress string `json:"contractAddress"`
-		Success         bool   `json:"success"`
-		TxHash          string `json:"transactionHash"`
-		TxIndex         uint   `json:"transactionIndex"`
-		Value           uint64 `json:"value"`
+		Block           uint64   `json:"block"`
+		From            string   `json:"from"`
+		To              string   `json:"to"`
+		ContractAddress string   `json:"contractAddress"`
+		Success         bool     `json:"success"`
+		TxHash          string   `json:"transactionHash"`
+		TxIndex         uint     `json:"transactionIndex"`
+		Value           *big.Int `json:"value"`
 	}
 )
 
@@ -29,29 +33,85 @@ func (s *Sub) processEventHandler(ctx context.Context, msg *nats.Msg) error {
 		return err
 	}
 
-	if err := s.cu.Store.UpdateDispatchStatus(
+	otx, updated, err := s.cu.Store.UpdateDispatchStatus(
 		ctx,
 		chainEvent.Success,
 		chainEvent.TxHash,
 		chainEvent.Block,
-	); err != nil {
+	)
+	if err != nil {
 		return err
 	}
 
+	if !updated {
+		// A redelivered chain event has already updated the otx, republish in case the first delivery failed to.
+		// Every publish is deduplicated so a successful first delivery is not published twice.
+		var (
+			status enum.OtxStatus
+			found  bool
+		)
+
+		otx, status, found, err = s.cu.Store.GetMinedOtx(ctx, chainEvent.TxHash)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			chainEvent.Success = status == enum.SUCCESS
+			updated = true
+		}
+	}
+
+	if updated {
+		otxEvent := pub.OtxEvent{
+			TrackingId: otx.TrackingId,
+			TxHash:     otx.TxHash,
+			Type:       string(otx.Type),
+			From:       otx.From,
+			Nonce:      otx.Nonce,
+			Status:     string(enum.SUCCESS),
+			Block:      chainEvent.Block,
+		}
+		subject := pub.OtxSuccessSubject
+
+		if !chainEvent.Success {
+			otxEvent.Status = string(enum.REVERTED)
+			subject = pub.OtxRevertedSubject
+		}
+
+		if err := task.NotifyOtxEvent(ctx, s.cu, subject, otxEvent); err != nil {
+			return err
+		}
+	}
+
 	if chainEvent.Success {
+		accountEvent := pub.AccountEvent{
+			PublicKey: chainEvent.To,
+			TxHash:    chainEvent.TxHash,
+		}
+
 		switch msg.Subject {
 		case "CHAIN.register":
 			if err := s.cu.Store.ActivateAccount(ctx, chainEvent.To); err != nil {
 				return err
 			}
+			if err := s.cu.Pub.PublishAccountEvent(pub.AccountActivatedSubject, accountEvent); err != nil {
+				return err
+			}
 
 			if err := s.cu.Store.GasUnlock(ctx, chainEvent.To); err != nil {
 				return err
 			}
+			if err := s.cu.Pub.PublishAccountEvent(pub.GasUnlockedSubject, accountEvent); err != nil {
+				return err
+			}
 		case "CHAIN.gas":
 			if err := s.cu.Store.GasUnlock(ctx, chainEvent.To); err != nil {
 				return err
 			}
+			if err := s.cu.Pub.PublishAccountEvent(pub.GasUnlockedSubject, accountEvent); err != nil {
+				return err
+			}
 		}
 	}
 
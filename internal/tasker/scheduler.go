@@ -0,0 +1,62 @@
+package tasker
+
+import (
+	"time"
+
+	"github.com/grassrootseconomics/cic-custodial/pkg/logg"
+	"github.com/grassrootseconomics/cic-custodial/pkg/redis"
+	"github.com/hibiken/asynq"
+	"github.com/zerodha/logf"
+)
+
+type TaskerSchedulerOpts struct {
+	Logg      logf.Logger
+	LogLevel  asynq.LogLevel
+	RedisPool *redis.RedisPool
+}
+
+type TaskerScheduler struct {
+	scheduler *asynq.Scheduler
+}
+
+func NewTaskerScheduler(o TaskerSchedulerOpts) *TaskerScheduler {
+	return &TaskerScheduler{
+		scheduler: asynq.NewScheduler(
+			o.RedisPool,
+			&asynq.SchedulerOpts{
+				Logger:   logg.AsynqCompatibleLogger(o.Logg),
+				LogLevel: o.LogLevel,
+			},
+		),
+	}
+}
+
+// RegisterPeriodicTask enqueues a payload-less task every interval.
+// Every replica runs its own scheduler, the unique option prevents the same periodic task from being queued more than once per interval.
+func (ts *TaskerScheduler) RegisterPeriodicTask(interval time.Duration, taskName TaskName, queueName QueueName) error {
+	_, err := ts.scheduler.Register(
+		"@every "+interval.String(),
+		asynq.NewTask(string(taskName), nil),
+		asynq.Queue(string(queueName)),
+		asynq.Retention(taskRetention),
+		asynq.Timeout(taskTimeout),
+		asynq.Unique(interval),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ts *TaskerScheduler) Start() error {
+	if err := ts.scheduler.Start(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ts *TaskerScheduler) Stop() {
+	ts.scheduler.Shutdown()
+}
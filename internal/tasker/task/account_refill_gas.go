@@ -6,10 +6,11 @@ import (
 	"math/big"
 	"time"
 
-	"github.com/bsm/redislock"
 	"github.com/celo-org/celo-blockchain/common/hexutil"
 	"github.com/grassrootseconomics/celoutils"
 	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/signer"
 	"github.com/grassrootseconomics/cic-custodial/internal/store"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
 	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
@@ -77,26 +78,24 @@ func AccountRefillGasProcessor(cu *custodial.Custodial) func(context.Context, *a
 			return nil
 		}
 
-		lock, err := cu.LockProvider.Obtain(
-			ctx,
-			lockPrefix+cu.SystemPublicKey,
-			lockTimeout,
-			&redislock.Options{
-				RetryStrategy: lockRetry(),
-			},
-		)
+		systemAccount, lock, err := obtainSystemSigner(ctx, cu)
 		if err != nil {
 			return err
 		}
 		defer lock.Release(ctx)
 
-		nonce, err := cu.Noncestore.Acquire(ctx, cu.SystemPublicKey)
+		fees, err := cu.FeeOracle.Fees(ctx)
+		if err != nil {
+			return err
+		}
+
+		nonce, err := cu.Noncestore.Acquire(ctx, systemAccount)
 		if err != nil {
 			return err
 		}
 		defer func() {
 			if err != nil {
-				if nErr := cu.Noncestore.Return(ctx, cu.SystemPublicKey); nErr != nil {
+				if nErr := cu.Noncestore.Return(ctx, systemAccount); nErr != nil {
 					err = nErr
 				}
 			}
@@ -109,16 +108,17 @@ func AccountRefillGasProcessor(cu *custodial.Custodial) func(context.Context, *a
 			return err
 		}
 
-		builtTx, err := cu.CeloProvider.SignContractExecutionTx(
-			cu.SystemPrivateKey,
-			celoutils.ContractExecutionTxOpts{
+		builtTx, err := cu.Signer.SignTx(
+			ctx,
+			systemAccount,
+			signer.ContractExecutionTx(celoutils.ContractExecutionTxOpts{
 				ContractAddress: cu.RegistryMap[celoutils.GasFaucet],
 				InputData:       input,
-				GasFeeCap:       celoutils.SafeGasFeeCap,
-				GasTipCap:       celoutils.SafeGasTipCap,
+				GasFeeCap:       fees.GasFeeCap,
+				GasTipCap:       fees.GasTipCap,
 				GasLimit:        uint64(celoutils.SafeGasLimit),
 				Nonce:           nonce,
-			},
+			}),
 		)
 		if err != nil {
 			return err
@@ -129,21 +129,26 @@ func AccountRefillGasProcessor(cu *custodial.Custodial) func(context.Context, *a
 			return err
 		}
 
-		id, err := cu.Store.CreateOtx(ctx, store.Otx{
+		otx := store.Otx{
 			TrackingId: payload.TrackingId,
 			Type:       enum.REFILL_GAS,
 			RawTx:      hexutil.Encode(rawTx),
 			TxHash:     builtTx.Hash().Hex(),
-			From:       cu.SystemPublicKey,
+			From:       systemAccount,
 			Data:       hexutil.Encode(builtTx.Data()),
 			GasPrice:   builtTx.GasPrice(),
 			GasLimit:   builtTx.Gas(),
 			Nonce:      builtTx.Nonce(),
-		})
+			ClientId:   payload.ClientId,
+		}
+
+		id, err := cu.Store.CreateOtx(ctx, otx)
 		if err != nil {
 			return err
 		}
 
+		notifyOtxEvent(ctx, cu, pub.OtxSignedSubject, otx, enum.SIGNED)
+
 		disptachJobPayload, err := json.Marshal(TxPayload{
 			OtxId: id,
 			Tx:    builtTx,
@@ -4,10 +4,11 @@ import (
 	"context"
 	"encoding/json"
 
-	"github.com/bsm/redislock"
 	"github.com/celo-org/celo-blockchain/common/hexutil"
 	"github.com/grassrootseconomics/celoutils"
 	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/signer"
 	"github.com/grassrootseconomics/cic-custodial/internal/store"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
 	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
@@ -15,6 +16,7 @@ import (
 )
 
 type AccountPayload struct {
+	ClientId   uint   `json:"clientId"`
 	PublicKey  string `json:"publicKey"`
 	TrackingId string `json:"trackingId"`
 }
@@ -30,26 +32,24 @@ func AccountRegisterOnChainProcessor(cu *custodial.Custodial) func(context.Conte
 			return err
 		}
 
-		lock, err := cu.LockProvider.Obtain(
-			ctx,
-			lockPrefix+cu.SystemPublicKey,
-			lockTimeout,
-			&redislock.Options{
-				RetryStrategy: lockRetry(),
-			},
-		)
+		systemAccount, lock, err := obtainSystemSigner(ctx, cu)
 		if err != nil {
 			return err
 		}
 		defer lock.Release(ctx)
 
-		nonce, err := cu.Noncestore.Acquire(ctx, cu.SystemPublicKey)
+		fees, err := cu.FeeOracle.Fees(ctx)
+		if err != nil {
+			return err
+		}
+
+		nonce, err := cu.Noncestore.Acquire(ctx, systemAccount)
 		if err != nil {
 			return err
 		}
 		defer func() {
 			if err != nil {
-				if nErr := cu.Noncestore.Return(ctx, cu.SystemPublicKey); nErr != nil {
+				if nErr := cu.Noncestore.Return(ctx, systemAccount); nErr != nil {
 					err = nErr
 				}
 			}
@@ -62,16 +62,17 @@ func AccountRegisterOnChainProcessor(cu *custodial.Custodial) func(context.Conte
 			return err
 		}
 
-		builtTx, err := cu.CeloProvider.SignContractExecutionTx(
-			cu.SystemPrivateKey,
-			celoutils.ContractExecutionTxOpts{
+		builtTx, err := cu.Signer.SignTx(
+			ctx,
+			systemAccount,
+			signer.ContractExecutionTx(celoutils.ContractExecutionTxOpts{
 				ContractAddress: cu.RegistryMap[celoutils.CustodialProxy],
 				InputData:       input,
-				GasFeeCap:       celoutils.SafeGasFeeCap,
-				GasTipCap:       celoutils.SafeGasTipCap,
+				GasFeeCap:       fees.GasFeeCap,
+				GasTipCap:       fees.GasTipCap,
 				GasLimit:        uint64(celoutils.SafeGasLimit),
 				Nonce:           nonce,
-			},
+			}),
 		)
 		if err != nil {
 			return err
@@ -82,21 +83,26 @@ func AccountRegisterOnChainProcessor(cu *custodial.Custodial) func(context.Conte
 			return err
 		}
 
-		id, err := cu.Store.CreateOtx(ctx, store.Otx{
+		otx := store.Otx{
 			TrackingId: payload.TrackingId,
 			Type:       enum.ACCOUNT_REGISTER,
 			RawTx:      hexutil.Encode(rawTx),
 			TxHash:     builtTx.Hash().Hex(),
-			From:       cu.SystemPublicKey,
+			From:       systemAccount,
 			Data:       hexutil.Encode(builtTx.Data()),
 			GasPrice:   builtTx.GasPrice(),
 			GasLimit:   builtTx.Gas(),
 			Nonce:      builtTx.Nonce(),
-		})
+			ClientId:   payload.ClientId,
+		}
+
+		id, err := cu.Store.CreateOtx(ctx, otx)
 		if err != nil {
 			return err
 		}
 
+		notifyOtxEvent(ctx, cu, pub.OtxSignedSubject, otx, enum.SIGNED)
+
 		disptachJobPayload, err := json.Marshal(TxPayload{
 			OtxId: id,
 			Tx:    builtTx,
@@ -49,6 +49,14 @@ func DispatchTx(cu *custodial.Custodial) func(context.Context, *asynq.Task) erro
 			if err := cu.Store.CreateDispatchStatus(ctx, payload.OtxId, dispatchStatus); err != nil {
 				return fmt.Errorf("dispatch: failed %v: %w", err, asynq.SkipRetry)
 			}
+			notifyDispatchEvent(ctx, cu, payload.OtxId, dispatchStatus)
+
+			// The nonce is consumed, replace immediately rather than waiting for the next stuck tx scan.
+			if dispatchStatus == enum.FAIL_LOW_GAS_PRICE {
+				if err := enqueueReplaceTx(ctx, cu, payload.OtxId); err != nil {
+					cu.Logg.Error("dispatch: failed to queue replacement", "otx_id", payload.OtxId, "error", err)
+				}
+			}
 
 			return fmt.Errorf("dispatch: failed %v: %w", err, asynq.SkipRetry)
 		}
@@ -56,6 +64,7 @@ func DispatchTx(cu *custodial.Custodial) func(context.Context, *asynq.Task) erro
 		if err := cu.Store.CreateDispatchStatus(ctx, payload.OtxId, dispatchStatus); err != nil {
 			return fmt.Errorf("dispatch: failed %v: %w", err, asynq.SkipRetry)
 		}
+		notifyDispatchEvent(ctx, cu, payload.OtxId, dispatchStatus)
 
 		return nil
 	}
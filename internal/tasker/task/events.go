@@ -0,0 +1,149 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
+	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
+	"github.com/hibiken/asynq"
+)
+
+type PublishEventPayload struct {
+	Subject string          `json:"subject"`
+	DedupId string          `json:"dedupId"`
+	Event   json.RawMessage `json:"event"`
+}
+
+// PublishEventProcessor retries an event publish which failed after its otx or account state was committed.
+func PublishEventProcessor(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var (
+			payload PublishEventPayload
+		)
+
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			return err
+		}
+
+		return cu.Pub.Publish(payload.Subject, payload.DedupId, payload.Event)
+	}
+}
+
+// NotifyOtxEvent publishes an otx status change to the CUSTODIAL stream and the track streams and queues its webhook delivery.
+// Every step is deduplicated, an error is returned so that the caller can redeliver the whole notification.
+func NotifyOtxEvent(ctx context.Context, cu *custodial.Custodial, subject string, event pub.OtxEvent) error {
+	event.Timestamp = time.Now().Unix()
+
+	if err := notifyStatusEvent(ctx, cu, subject, event); err != nil {
+		return err
+	}
+
+	return cu.Pub.PublishOtxEvent(subject, event)
+}
+
+// notifyStatusEvent publishes the track event and queues the webhook delivery of an otx status change.
+// Track streams are best effort, only a failure to queue the webhook is returned.
+func notifyStatusEvent(ctx context.Context, cu *custodial.Custodial, subject string, event pub.OtxEvent) error {
+	statusEvent := pub.NewStatusEvent(subject, event)
+
+	if err := cu.PublishTrackEvent(ctx, statusEvent); err != nil {
+		cu.Logg.Error("track: failed to publish track event", "tracking_id", event.TrackingId, "tx_hash", event.TxHash, "error", err)
+	}
+
+	// Webhooks are only delivered for dispatch, mine and failure status changes.
+	if subject == pub.OtxSignedSubject {
+		return nil
+	}
+
+	return enqueueWebhook(ctx, cu, statusEvent)
+}
+
+// notifyTaskOtxEvent notifies an otx status change from a task.
+// The otx state is already committed and the task is not retried, a failed publish is queued as its own task instead.
+func notifyTaskOtxEvent(ctx context.Context, cu *custodial.Custodial, subject string, event pub.OtxEvent) {
+	event.Timestamp = time.Now().Unix()
+
+	if err := notifyStatusEvent(ctx, cu, subject, event); err != nil {
+		cu.Logg.Error("webhook: failed to queue delivery", "tracking_id", event.TrackingId, "tx_hash", event.TxHash, "error", err)
+	}
+
+	if err := cu.Pub.PublishOtxEvent(subject, event); err != nil {
+		cu.Logg.Warn("pub: failed to publish otx event, queuing retry", "subject", subject, "tracking_id", event.TrackingId, "tx_hash", event.TxHash, "error", err)
+		enqueuePublishEvent(ctx, cu, subject, pub.OtxEventDedupId(subject, event), event)
+	}
+}
+
+func notifyOtxEvent(ctx context.Context, cu *custodial.Custodial, subject string, otx store.Otx, status enum.OtxStatus) {
+	notifyTaskOtxEvent(ctx, cu, subject, pub.OtxEvent{
+		TrackingId: otx.TrackingId,
+		TxHash:     otx.TxHash,
+		Type:       string(otx.Type),
+		From:       otx.From,
+		Nonce:      otx.Nonce,
+		Status:     string(status),
+	})
+}
+
+// notifyDispatchEvent loads the dispatched otx and notifies its dispatch outcome.
+func notifyDispatchEvent(ctx context.Context, cu *custodial.Custodial, otxId uint, status enum.OtxStatus) {
+	otx, _, err := cu.Store.GetOtx(ctx, otxId)
+	if err != nil {
+		cu.Logg.Error("pub: failed to load otx for dispatch event", "otx_id", otxId, "error", err)
+		return
+	}
+
+	subject := pub.OtxDispatchedSubject
+	if status != enum.IN_NETWORK {
+		subject = pub.OtxDispatchFailedSubject
+	}
+
+	notifyOtxEvent(ctx, cu, subject, otx, status)
+}
+
+// publishAccountEvent publishes an account lifecycle event from a task, a failed publish is queued as its own task.
+func publishAccountEvent(ctx context.Context, cu *custodial.Custodial, subject string, event pub.AccountEvent) {
+	event.Timestamp = time.Now().Unix()
+
+	if err := cu.Pub.PublishAccountEvent(subject, event); err != nil {
+		cu.Logg.Warn("pub: failed to publish account event, queuing retry", "subject", subject, "public_key", event.PublicKey, "error", err)
+		enqueuePublishEvent(ctx, cu, subject, pub.AccountEventDedupId(subject, event), event)
+	}
+}
+
+// enqueuePublishEvent queues a publish retry under the event dedup id, the event is only lost if queuing fails as well.
+func enqueuePublishEvent(ctx context.Context, cu *custodial.Custodial, subject string, dedupId string, event interface{}) {
+	eventJson, err := json.Marshal(event)
+	if err != nil {
+		cu.Logg.Error("pub: failed to queue event publish", "subject", subject, "dedup_id", dedupId, "error", err)
+		return
+	}
+
+	publishEventPayload, err := json.Marshal(PublishEventPayload{
+		Subject: subject,
+		DedupId: dedupId,
+		Event:   eventJson,
+	})
+	if err != nil {
+		cu.Logg.Error("pub: failed to queue event publish", "subject", subject, "dedup_id", dedupId, "error", err)
+		return
+	}
+
+	_, err = cu.TaskerClient.CreateTask(
+		ctx,
+		tasker.PublishEventTask,
+		tasker.DefaultPriority,
+		&tasker.Task{
+			Id:      "publish:" + dedupId,
+			Payload: publishEventPayload,
+		},
+	)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		cu.Logg.Error("pub: failed to queue event publish", "subject", subject, "dedup_id", dedupId, "error", err)
+	}
+}
@@ -0,0 +1,306 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/bsm/redislock"
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/rpc"
+	"github.com/google/uuid"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/signer"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
+	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
+	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// An otx younger than the grace period may still be queued for dispatch.
+	nonceGapGracePeriod = 2 * time.Minute
+	// maxNonceGapRepair limits the nonces filled per account per scan.
+	maxNonceGapRepair = 10
+	// reconcileLockTimeout covers the RPC calls of a reconcile, the lock is refreshed before every fill.
+	reconcileLockTimeout = 30 * time.Second
+)
+
+var (
+	nonceGapsDetected = metrics.NewCounter("custodial_nonce_gaps_detected_total")
+)
+
+// pendingNonceCaller implements the w3types.Caller interface for eth_getTransactionCount at the pending block.
+// eth.Nonce only supports the latest block.
+type pendingNonceCaller struct {
+	address common.Address
+	returns *hexutil.Uint64
+}
+
+func (c *pendingNonceCaller) CreateRequest() (rpc.BatchElem, error) {
+	return rpc.BatchElem{
+		Method: "eth_getTransactionCount",
+		Args:   []any{c.address, "pending"},
+		Result: c.returns,
+	}, nil
+}
+
+func (c *pendingNonceCaller) HandleResponse(elem rpc.BatchElem) error {
+	return elem.Error
+}
+
+// ReconcileNonceProcessor compares the noncestore, the otx_sign table and the network nonce of every recently active account and system account.
+// A gap (a nonce the network has never seen below the highest dispatched nonce) stalls every later tx of the account in the mempool.
+// Gaps are always reported, they are only repaired if enabled.
+func ReconcileNonceProcessor(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+		accounts, err := cu.Store.GetActiveAccounts(ctx, cu.ReconcileWindow)
+		if err != nil {
+			return err
+		}
+
+		accounts = append(accounts, cu.SystemPool...)
+		reconciled := make(map[string]bool)
+
+		for _, account := range accounts {
+			if reconciled[account] {
+				continue
+			}
+			reconciled[account] = true
+
+			if err := reconcileNonce(ctx, cu, account); err != nil {
+				cu.Logg.Error("reconcile: failed to reconcile account nonce", "account", account, "error", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func reconcileNonce(ctx context.Context, cu *custodial.Custodial, publicKey string) error {
+	var (
+		latestNonce  uint64
+		pendingNonce hexutil.Uint64
+	)
+
+	// Processors hold the account lock from acquiring a nonce until the otx is stored.
+	lock, err := cu.LockProvider.Obtain(
+		ctx,
+		lockPrefix+publicKey,
+		reconcileLockTimeout,
+		&redislock.Options{
+			RetryStrategy: lockRetry(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer lock.Release(ctx)
+
+	if err := cu.CeloProvider.Client.CallCtx(
+		ctx,
+		eth.Nonce(celoutils.HexToAddress(publicKey), nil).Returns(&latestNonce),
+		&pendingNonceCaller{
+			address: celoutils.HexToAddress(publicKey),
+			returns: &pendingNonce,
+		},
+	); err != nil {
+		return err
+	}
+
+	// The next nonce after the highest signed otx, replacements and nonce fills are signed later at lower nonces.
+	dbNonce, err := cu.Store.GetNextNonce(ctx, publicKey)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	// Only dispatched otx are expected in the network, otx still queued for dispatch are not a gap.
+	dispatchedNonce, err := cu.Store.GetNextDispatchedNonce(ctx, publicKey)
+	if err != nil {
+		return err
+	}
+
+	storeNonce, err := cu.Noncestore.Peek(ctx, publicKey)
+	if err != nil {
+		return err
+	}
+
+	// The noncestore would hand out a nonce that is already used.
+	if storeNonce < dbNonce || storeNonce < latestNonce {
+		expectedNonce := maxNonce(dbNonce, uint64(pendingNonce))
+		cu.Logg.Warn("reconcile: noncestore behind", "account", publicKey, "noncestore", storeNonce, "expected", expectedNonce)
+
+		if cu.NonceRepair {
+			if err := cu.Noncestore.SetAccountNonce(ctx, publicKey, expectedNonce); err != nil {
+				return err
+			}
+			storeNonce = expectedNonce
+		}
+	}
+
+	if uint64(pendingNonce) >= dispatchedNonce {
+		return nil
+	}
+
+	nonceGapsDetected.Inc()
+	cu.Logg.Warn(
+		"reconcile: nonce gap detected",
+		"account", publicKey,
+		"network_latest", latestNonce,
+		"network_pending", uint64(pendingNonce),
+		"otx_next", dbNonce,
+		"otx_dispatched_next", dispatchedNonce,
+		"noncestore_next", storeNonce,
+	)
+
+	if !cu.NonceRepair {
+		return nil
+	}
+
+	for nonce := uint64(pendingNonce); nonce < dispatchedNonce && nonce < uint64(pendingNonce)+maxNonceGapRepair; nonce++ {
+		if err := lock.Refresh(ctx, reconcileLockTimeout, nil); err != nil {
+			return err
+		}
+
+		if err := fillNonce(ctx, cu, publicKey, nonce); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fillNonce re-dispatches the stored otx at a missing nonce or signs a zero value self transfer if there is none.
+func fillNonce(ctx context.Context, cu *custodial.Custodial, publicKey string, nonce uint64) error {
+	otxId, err := cu.Store.GetOtxIdByNonce(ctx, publicKey, nonce)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return signNonceFill(ctx, cu, publicKey, nonce)
+		}
+		return err
+	}
+
+	otx, status, err := cu.Store.GetOtx(ctx, otxId)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case enum.SUCCESS, enum.REVERTED:
+		return nil
+	case enum.FAIL_LOW_GAS_PRICE:
+		return enqueueReplaceTx(ctx, cu, otx.Id)
+	}
+
+	if time.Since(otx.CreatedAt) < nonceGapGracePeriod {
+		return nil
+	}
+
+	rawTx, err := hexutil.Decode(otx.RawTx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		txHash common.Hash
+	)
+
+	if err := cu.CeloProvider.Client.CallCtx(
+		ctx,
+		eth.SendRawTx(rawTx).Returns(&txHash),
+	); err != nil {
+		if err.Error() == celoutils.ErrNonceLow {
+			return nil
+		}
+		cu.Logg.Error("reconcile: failed to re-dispatch otx", "otx_id", otx.Id, "nonce", nonce, "error", err)
+		return nil
+	}
+
+	cu.Logg.Info("reconcile: re-dispatched otx", "otx_id", otx.Id, "account", publicKey, "nonce", nonce)
+	return cu.Store.SetDispatchStatus(ctx, otx.Id, enum.IN_NETWORK)
+}
+
+func signNonceFill(ctx context.Context, cu *custodial.Custodial, publicKey string, nonce uint64) error {
+	fees, err := cu.FeeOracle.Fees(ctx)
+	if err != nil {
+		return err
+	}
+
+	builtTx, err := cu.Signer.SignTx(
+		ctx,
+		publicKey,
+		signer.GasTransferTx(celoutils.GasTransferTxOpts{
+			To:        celoutils.HexToAddress(publicKey),
+			Value:     big.NewInt(0),
+			GasFeeCap: fees.GasFeeCap,
+			GasTipCap: fees.GasTipCap,
+			Nonce:     nonce,
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	rawTx, err := builtTx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	otx := store.Otx{
+		TrackingId:    uuid.NewString(),
+		Type:          enum.NONCE_FILL,
+		RawTx:         hexutil.Encode(rawTx),
+		TxHash:        builtTx.Hash().Hex(),
+		From:          publicKey,
+		Data:          hexutil.Encode(builtTx.Data()),
+		GasPrice:      builtTx.GasPrice(),
+		GasLimit:      builtTx.Gas(),
+		TransferValue: big.NewInt(0),
+		Nonce:         builtTx.Nonce(),
+	}
+
+	id, err := cu.Store.CreateOtx(ctx, otx)
+	if err != nil {
+		return err
+	}
+
+	notifyOtxEvent(ctx, cu, pub.OtxSignedSubject, otx, enum.SIGNED)
+
+	disptachJobPayload, err := json.Marshal(TxPayload{
+		OtxId: id,
+		Tx:    builtTx,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = cu.TaskerClient.CreateTask(
+		ctx,
+		tasker.DispatchTxTask,
+		tasker.HighPriority,
+		&tasker.Task{
+			Payload: disptachJobPayload,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	cu.Logg.Info("reconcile: signed nonce fill", "otx_id", id, "account", publicKey, "nonce", nonce)
+	return nil
+}
+
+func maxNonce(a uint64, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
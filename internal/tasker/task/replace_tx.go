@@ -0,0 +1,285 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bsm/redislock"
+	"github.com/celo-org/celo-blockchain/common/hexutil"
+	"github.com/celo-org/celo-blockchain/core/types"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/signer"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
+	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
+	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	stuckTxScanLimit = 100
+	// replaceLockTimeout outlasts the RPC calls and signing a replacement does while holding the account lock.
+	replaceLockTimeout = 30 * time.Second
+)
+
+type ReplaceTxPayload struct {
+	OtxId uint `json:"otxId"`
+}
+
+// ScanStuckTxProcessor periodically queues a replacement for every otx stuck in the network or rejected for a low gas price.
+func ScanStuckTxProcessor(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+		otxIds, err := cu.Store.GetStuckOtx(ctx, cu.StuckTxTimeout, stuckTxScanLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, otxId := range otxIds {
+			if err := enqueueReplaceTx(ctx, cu, otxId); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// ReplaceTxProcessor re-signs an otx at the same nonce and calldata with a bumped gas fee.
+// The replacement is linked to the original otx which is marked as OBSOLETE.
+func ReplaceTxProcessor(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var (
+			err          error
+			networkNonce uint64
+			payload      ReplaceTxPayload
+		)
+
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			return err
+		}
+
+		otx, status, err := cu.Store.GetOtx(ctx, payload.OtxId)
+		if err != nil {
+			return err
+		}
+
+		if !isReplaceable(status) {
+			return nil
+		}
+
+		lock, err := cu.LockProvider.Obtain(
+			ctx,
+			lockPrefix+otx.From,
+			replaceLockTimeout,
+			&redislock.Options{
+				RetryStrategy: lockRetry(),
+			},
+		)
+		if err != nil {
+			return err
+		}
+		defer lock.Release(ctx)
+
+		// A concurrent replacement, reconcile or chain event may have settled the otx while the lock was awaited.
+		otx, status, err = cu.Store.GetOtx(ctx, payload.OtxId)
+		if err != nil {
+			return err
+		}
+
+		if !isReplaceable(status) {
+			return nil
+		}
+
+		rawTx, err := hexutil.Decode(otx.RawTx)
+		if err != nil {
+			return err
+		}
+
+		originalTx := new(types.Transaction)
+		if err := originalTx.UnmarshalBinary(rawTx); err != nil {
+			return err
+		}
+
+		if originalTx.To() == nil {
+			return fmt.Errorf("replace: otx %d is not a contract execution: %w", otx.Id, asynq.SkipRetry)
+		}
+
+		if err := cu.CeloProvider.Client.CallCtx(
+			ctx,
+			eth.Nonce(celoutils.HexToAddress(otx.From), nil).Returns(&networkNonce),
+		); err != nil {
+			return err
+		}
+
+		// The nonce was already mined, either by this otx (the chain event is yet to be processed) or by another tx.
+		if networkNonce > otx.Nonce {
+			var (
+				receipt types.Receipt
+			)
+
+			if err := cu.CeloProvider.Client.CallCtx(
+				ctx,
+				eth.TxReceipt(originalTx.Hash()).Returns(&receipt),
+			); err != nil {
+				if isNotFound(err) {
+					return cu.Store.MarkOtxObsolete(ctx, otx.Id)
+				}
+				return err
+			}
+
+			success := receipt.Status == types.ReceiptStatusSuccessful
+			_, updated, err := cu.Store.UpdateDispatchStatus(
+				ctx,
+				success,
+				otx.TxHash,
+				receipt.BlockNumber.Uint64(),
+			)
+			if err != nil {
+				return err
+			}
+
+			if updated {
+				if success {
+					notifyOtxEvent(ctx, cu, pub.OtxSuccessSubject, otx, enum.SUCCESS)
+				} else {
+					notifyOtxEvent(ctx, cu, pub.OtxRevertedSubject, otx, enum.REVERTED)
+				}
+			}
+
+			return nil
+		}
+
+		if originalTx.GasFeeCap().Cmp(cu.MaxGasFeeCap) >= 0 {
+			return fmt.Errorf("replace: otx %d already at max gas fee cap: %w", otx.Id, asynq.SkipRetry)
+		}
+
+		fees, err := cu.FeeOracle.Fees(ctx)
+		if err != nil {
+			return err
+		}
+
+		// The network fees may have risen by more than the bump since the original was signed.
+		gasFeeCap := bumpGas(originalTx.GasFeeCap(), cu.GasBumpPercent)
+		if fees.GasFeeCap.Cmp(gasFeeCap) > 0 {
+			gasFeeCap = fees.GasFeeCap
+		}
+		if gasFeeCap.Cmp(cu.MaxGasFeeCap) > 0 {
+			gasFeeCap = cu.MaxGasFeeCap
+		}
+
+		gasTipCap := bumpGas(originalTx.GasTipCap(), cu.GasBumpPercent)
+		if fees.GasTipCap.Cmp(gasTipCap) > 0 {
+			gasTipCap = fees.GasTipCap
+		}
+		if gasTipCap.Cmp(gasFeeCap) > 0 {
+			gasTipCap = gasFeeCap
+		}
+
+		builtTx, err := cu.Signer.SignTx(
+			ctx,
+			otx.From,
+			signer.ContractExecutionTx(celoutils.ContractExecutionTxOpts{
+				ContractAddress: *originalTx.To(),
+				InputData:       originalTx.Data(),
+				GasFeeCap:       gasFeeCap,
+				GasTipCap:       gasTipCap,
+				GasLimit:        originalTx.Gas(),
+				Nonce:           originalTx.Nonce(),
+			}),
+		)
+		if err != nil {
+			return err
+		}
+
+		replacementRawTx, err := builtTx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		replacementOtx := store.Otx{
+			TrackingId:    otx.TrackingId,
+			Type:          otx.Type,
+			RawTx:         hexutil.Encode(replacementRawTx),
+			TxHash:        builtTx.Hash().Hex(),
+			From:          otx.From,
+			Data:          hexutil.Encode(builtTx.Data()),
+			GasPrice:      builtTx.GasPrice(),
+			GasLimit:      builtTx.Gas(),
+			TransferValue: otx.TransferValue,
+			Nonce:         builtTx.Nonce(),
+			ClientId:      otx.ClientId,
+			Replaces:      otx.Id,
+
+			VoucherAddress: otx.VoucherAddress,
+		}
+
+		id, err := cu.Store.CreateOtx(ctx, replacementOtx)
+		if err != nil {
+			return err
+		}
+
+		notifyOtxEvent(ctx, cu, pub.OtxSignedSubject, replacementOtx, enum.SIGNED)
+
+		if err := cu.Store.MarkOtxObsolete(ctx, otx.Id); err != nil {
+			return err
+		}
+
+		disptachJobPayload, err := json.Marshal(TxPayload{
+			OtxId: id,
+			Tx:    builtTx,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = cu.TaskerClient.CreateTask(
+			ctx,
+			tasker.DispatchTxTask,
+			tasker.HighPriority,
+			&tasker.Task{
+				Payload: disptachJobPayload,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// isReplaceable checks whether an otx is still pending in a state a gas price bump can resolve.
+func isReplaceable(status enum.OtxStatus) bool {
+	return status == enum.IN_NETWORK || status == enum.FAIL_LOW_GAS_PRICE
+}
+
+// enqueueReplaceTx queues a replacement for an otx.
+// The task id is derived from the otx id so that the same otx is never queued for replacement twice.
+func enqueueReplaceTx(ctx context.Context, cu *custodial.Custodial, otxId uint) error {
+	replaceTxPayload, err := json.Marshal(ReplaceTxPayload{
+		OtxId: otxId,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = cu.TaskerClient.CreateTask(
+		ctx,
+		tasker.ReplaceTxTask,
+		tasker.HighPriority,
+		&tasker.Task{
+			Id:      fmt.Sprintf("replace-%d", otxId),
+			Payload: replaceTxPayload,
+		},
+	)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+
+	return nil
+}
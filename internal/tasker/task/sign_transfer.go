@@ -3,12 +3,15 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math/big"
 
 	"github.com/bsm/redislock"
 	"github.com/celo-org/celo-blockchain/common/hexutil"
 	"github.com/grassrootseconomics/celoutils"
 	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/signer"
 	"github.com/grassrootseconomics/cic-custodial/internal/store"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
 	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
@@ -17,11 +20,13 @@ import (
 )
 
 type TransferPayload struct {
+	ClientId       uint   `json:"clientId"`
 	TrackingId     string `json:"trackingId"`
 	From           string `json:"from" `
 	To             string `json:"to"`
 	VoucherAddress string `json:"voucherAddress"`
-	Amount         uint64 `json:"amount"`
+	// Amount is encoded as a JSON number, payloads queued when it was a uint64 decode unchanged.
+	Amount *big.Int `json:"amount"`
 }
 
 func SignTransfer(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
@@ -36,6 +41,38 @@ func SignTransfer(cu *custodial.Custodial) func(context.Context, *asynq.Task) er
 			return err
 		}
 
+		voucherAllowed, err := cu.IsVoucherAllowed(ctx, payload.VoucherAddress)
+		if err != nil {
+			return err
+		}
+
+		if !voucherAllowed {
+			return recordSignFailure(ctx, cu, payload.TrackingId, enum.TRANSFER_VOUCHER, payload.From, ErrVoucherNotAllowed)
+		}
+
+		input, err := cu.Abis[custodial.Transfer].EncodeArgs(
+			celoutils.HexToAddress(payload.To),
+			payload.Amount,
+		)
+		if err != nil {
+			return err
+		}
+
+		gasLimit, err := simulateTx(
+			ctx,
+			cu,
+			celoutils.HexToAddress(payload.From),
+			celoutils.HexToAddress(payload.VoucherAddress),
+			input,
+			cu.Abis[custodial.Transfer],
+		)
+		if err != nil {
+			if errors.Is(err, ErrCallWillRevert) {
+				return recordSignFailure(ctx, cu, payload.TrackingId, enum.TRANSFER_VOUCHER, payload.From, err)
+			}
+			return err
+		}
+
 		lock, err := cu.LockProvider.Obtain(
 			ctx,
 			lockPrefix+payload.From,
@@ -49,7 +86,17 @@ func SignTransfer(cu *custodial.Custodial) func(context.Context, *asynq.Task) er
 		}
 		defer lock.Release(ctx)
 
-		key, err := cu.Store.LoadPrivateKey(ctx, payload.From)
+		// Re-checked under the account lock, transfers signed since the request was accepted are now counted.
+		policyViolation, err := cu.Policy.CheckTransfer(ctx, payload.From, payload.VoucherAddress, payload.Amount)
+		if err != nil {
+			return err
+		}
+
+		if policyViolation != "" {
+			return recordSignFailure(ctx, cu, payload.TrackingId, enum.TRANSFER_VOUCHER, payload.From, errors.New(policyViolation))
+		}
+
+		fees, err := cu.FeeOracle.Fees(ctx)
 		if err != nil {
 			return err
 		}
@@ -66,24 +113,17 @@ func SignTransfer(cu *custodial.Custodial) func(context.Context, *asynq.Task) er
 			}
 		}()
 
-		input, err := cu.Abis[custodial.Transfer].EncodeArgs(
-			celoutils.HexToAddress(payload.To),
-			new(big.Int).SetUint64(payload.Amount),
-		)
-		if err != nil {
-			return err
-		}
-
-		builtTx, err := cu.CeloProvider.SignContractExecutionTx(
-			key,
-			celoutils.ContractExecutionTxOpts{
+		builtTx, err := cu.Signer.SignTx(
+			ctx,
+			payload.From,
+			signer.ContractExecutionTx(celoutils.ContractExecutionTxOpts{
 				ContractAddress: celoutils.HexToAddress(payload.VoucherAddress),
 				InputData:       input,
-				GasFeeCap:       celoutils.SafeGasFeeCap,
-				GasTipCap:       celoutils.SafeGasTipCap,
-				GasLimit:        uint64(celoutils.SafeGasLimit),
+				GasFeeCap:       fees.GasFeeCap,
+				GasTipCap:       fees.GasTipCap,
+				GasLimit:        gasLimit,
 				Nonce:           nonce,
-			},
+			}),
 		)
 		if err != nil {
 			return err
@@ -94,7 +134,7 @@ func SignTransfer(cu *custodial.Custodial) func(context.Context, *asynq.Task) er
 			return err
 		}
 
-		id, err := cu.Store.CreateOtx(ctx, store.Otx{
+		otx := store.Otx{
 			TrackingId:    payload.TrackingId,
 			Type:          enum.TRANSFER_VOUCHER,
 			RawTx:         hexutil.Encode(rawTx),
@@ -105,11 +145,18 @@ func SignTransfer(cu *custodial.Custodial) func(context.Context, *asynq.Task) er
 			GasLimit:      builtTx.Gas(),
 			TransferValue: payload.Amount,
 			Nonce:         builtTx.Nonce(),
-		})
+			ClientId:      payload.ClientId,
+
+			VoucherAddress: payload.VoucherAddress,
+		}
+
+		id, err := cu.Store.CreateOtx(ctx, otx)
 		if err != nil {
 			return err
 		}
 
+		notifyOtxEvent(ctx, cu, pub.OtxSignedSubject, otx, enum.SIGNED)
+
 		if err := cu.CeloProvider.Client.CallCtx(
 			ctx,
 			eth.Balance(celoutils.HexToAddress(payload.From), nil).Returns(&networkBalance),
@@ -138,6 +185,7 @@ func SignTransfer(cu *custodial.Custodial) func(context.Context, *asynq.Task) er
 		}
 
 		gasRefillPayload, err := json.Marshal(AccountPayload{
+			ClientId:   payload.ClientId,
 			PublicKey:  payload.From,
 			TrackingId: payload.TrackingId,
 		})
@@ -145,10 +193,14 @@ func SignTransfer(cu *custodial.Custodial) func(context.Context, *asynq.Task) er
 			return err
 		}
 
-		if !balanceCheck(networkBalance) {
+		if !balanceCheck(networkBalance, fees.GasFeeCap) {
 			if err := cu.Store.GasLock(ctx, payload.From); err != nil {
 				return err
 			}
+			publishAccountEvent(ctx, cu, pub.GasLockedSubject, pub.AccountEvent{
+				PublicKey:  payload.From,
+				TrackingId: payload.TrackingId,
+			})
 
 			_, err = cu.TaskerClient.CreateTask(
 				ctx,
@@ -3,12 +3,15 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math/big"
 
 	"github.com/bsm/redislock"
 	"github.com/celo-org/celo-blockchain/common/hexutil"
 	"github.com/grassrootseconomics/celoutils"
 	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/signer"
 	"github.com/grassrootseconomics/cic-custodial/internal/store"
 	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
 	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
@@ -17,11 +20,13 @@ import (
 )
 
 type TransferAuthPayload struct {
-	Amount            uint64 `json:"amount"`
-	Authorizer        string `json:"authorizer"`
-	AuthorizedAddress string `json:"authorizedAddress"`
-	TrackingId        string `json:"trackingId"`
-	VoucherAddress    string `json:"voucherAddress"`
+	// Amount is encoded as a JSON number, payloads queued when it was a uint64 decode unchanged.
+	Amount            *big.Int `json:"amount"`
+	Authorizer        string   `json:"authorizer"`
+	AuthorizedAddress string   `json:"authorizedAddress"`
+	ClientId          uint     `json:"clientId"`
+	TrackingId        string   `json:"trackingId"`
+	VoucherAddress    string   `json:"voucherAddress"`
 }
 
 func SignTransferAuthorizationProcessor(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
@@ -36,6 +41,41 @@ func SignTransferAuthorizationProcessor(cu *custodial.Custodial) func(context.Co
 			return err
 		}
 
+		// A revoke is always signed, it must still go through for a voucher removed from the allowlist during the approval window.
+		if payload.Amount.Sign() > 0 {
+			voucherAllowed, err := cu.IsVoucherAllowed(ctx, payload.VoucherAddress)
+			if err != nil {
+				return err
+			}
+
+			if !voucherAllowed {
+				return recordSignFailure(ctx, cu, payload.TrackingId, enum.TRANSFER_AUTH, payload.Authorizer, ErrVoucherNotAllowed)
+			}
+		}
+
+		input, err := cu.Abis[custodial.Approve].EncodeArgs(
+			celoutils.HexToAddress(payload.AuthorizedAddress),
+			payload.Amount,
+		)
+		if err != nil {
+			return err
+		}
+
+		gasLimit, err := simulateTx(
+			ctx,
+			cu,
+			celoutils.HexToAddress(payload.Authorizer),
+			celoutils.HexToAddress(payload.VoucherAddress),
+			input,
+			cu.Abis[custodial.Approve],
+		)
+		if err != nil {
+			if errors.Is(err, ErrCallWillRevert) {
+				return recordSignFailure(ctx, cu, payload.TrackingId, enum.TRANSFER_AUTH, payload.Authorizer, err)
+			}
+			return err
+		}
+
 		lock, err := cu.LockProvider.Obtain(
 			ctx,
 			lockPrefix+payload.Authorizer,
@@ -49,7 +89,17 @@ func SignTransferAuthorizationProcessor(cu *custodial.Custodial) func(context.Co
 		}
 		defer lock.Release(ctx)
 
-		key, err := cu.Store.LoadPrivateKey(ctx, payload.Authorizer)
+		// Re-checked under the account lock, transfers and approvals signed since the request was accepted are now counted.
+		policyViolation, err := cu.Policy.CheckApproval(ctx, payload.Authorizer, payload.VoucherAddress, payload.Amount)
+		if err != nil {
+			return err
+		}
+
+		if policyViolation != "" {
+			return recordSignFailure(ctx, cu, payload.TrackingId, enum.TRANSFER_AUTH, payload.Authorizer, errors.New(policyViolation))
+		}
+
+		fees, err := cu.FeeOracle.Fees(ctx)
 		if err != nil {
 			return err
 		}
@@ -66,24 +116,17 @@ func SignTransferAuthorizationProcessor(cu *custodial.Custodial) func(context.Co
 			}
 		}()
 
-		input, err := cu.Abis[custodial.Approve].EncodeArgs(
-			celoutils.HexToAddress(payload.AuthorizedAddress),
-			new(big.Int).SetUint64(payload.Amount),
-		)
-		if err != nil {
-			return err
-		}
-
-		builtTx, err := cu.CeloProvider.SignContractExecutionTx(
-			key,
-			celoutils.ContractExecutionTxOpts{
+		builtTx, err := cu.Signer.SignTx(
+			ctx,
+			payload.Authorizer,
+			signer.ContractExecutionTx(celoutils.ContractExecutionTxOpts{
 				ContractAddress: celoutils.HexToAddress(payload.VoucherAddress),
 				InputData:       input,
-				GasFeeCap:       celoutils.SafeGasFeeCap,
-				GasTipCap:       celoutils.SafeGasTipCap,
-				GasLimit:        uint64(celoutils.SafeGasLimit),
+				GasFeeCap:       fees.GasFeeCap,
+				GasTipCap:       fees.GasTipCap,
+				GasLimit:        gasLimit,
 				Nonce:           nonce,
-			},
+			}),
 		)
 		if err != nil {
 			return err
@@ -94,22 +137,32 @@ func SignTransferAuthorizationProcessor(cu *custodial.Custodial) func(context.Co
 			return err
 		}
 
-		id, err := cu.Store.CreateOtx(ctx, store.Otx{
+		// The approved amount is recorded as the transfer value, it counts toward the transfer policy daily value.
+		// The approval is signed by the authorizer with its own nonce, so it is recorded under the authorizer for nonce reconciliation,
+		// policy usage and history. Approvals recorded under the system key before are corrected with the backfill-approval-sender admin command.
+		otx := store.Otx{
 			TrackingId:    payload.TrackingId,
 			Type:          enum.TRANSFER_AUTH,
 			RawTx:         hexutil.Encode(rawTx),
 			TxHash:        builtTx.Hash().Hex(),
-			From:          cu.SystemPublicKey,
+			From:          payload.Authorizer,
 			Data:          hexutil.Encode(builtTx.Data()),
 			GasPrice:      builtTx.GasPrice(),
 			GasLimit:      builtTx.Gas(),
-			TransferValue: 0,
+			TransferValue: payload.Amount,
 			Nonce:         builtTx.Nonce(),
-		})
+			ClientId:      payload.ClientId,
+
+			VoucherAddress: payload.VoucherAddress,
+		}
+
+		id, err := cu.Store.CreateOtx(ctx, otx)
 		if err != nil {
 			return err
 		}
 
+		notifyOtxEvent(ctx, cu, pub.OtxSignedSubject, otx, enum.SIGNED)
+
 		if err := cu.CeloProvider.Client.CallCtx(
 			ctx,
 			eth.Balance(celoutils.HexToAddress(payload.Authorizer), nil).Returns(&networkBalance),
@@ -139,12 +192,13 @@ func SignTransferAuthorizationProcessor(cu *custodial.Custodial) func(context.Co
 
 		// Auto-revoke every session (15 min)
 		// Check if already a revoke request
-		if payload.Amount > 0 {
+		if payload.Amount.Sign() > 0 {
 			taskPayload, err := json.Marshal(TransferAuthPayload{
 				TrackingId:        payload.TrackingId,
-				Amount:            0,
+				Amount:            big.NewInt(0),
 				Authorizer:        payload.Authorizer,
 				AuthorizedAddress: payload.AuthorizedAddress,
+				ClientId:          payload.ClientId,
 				VoucherAddress:    payload.VoucherAddress,
 			})
 			if err != nil {
@@ -166,6 +220,7 @@ func SignTransferAuthorizationProcessor(cu *custodial.Custodial) func(context.Co
 		}
 
 		gasRefillPayload, err := json.Marshal(AccountPayload{
+			ClientId:   payload.ClientId,
 			PublicKey:  payload.Authorizer,
 			TrackingId: payload.TrackingId,
 		})
@@ -173,10 +228,14 @@ func SignTransferAuthorizationProcessor(cu *custodial.Custodial) func(context.Co
 			return err
 		}
 
-		if !balanceCheck(networkBalance) {
+		if !balanceCheck(networkBalance, fees.GasFeeCap) {
 			if err := cu.Store.GasLock(ctx, payload.Authorizer); err != nil {
 				return err
 			}
+			publishAccountEvent(ctx, cu, pub.GasLockedSubject, pub.AccountEvent{
+				PublicKey:  payload.Authorizer,
+				TrackingId: payload.TrackingId,
+			})
 
 			_, err = cu.TaskerClient.CreateTask(
 				ctx,
@@ -0,0 +1,134 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/celo-org/celo-blockchain/core/vm"
+	"github.com/celo-org/celo-blockchain/rpc"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/pkg/enum"
+	"github.com/grassrootseconomics/w3-celo-patch"
+	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
+	"github.com/grassrootseconomics/w3-celo-patch/w3types"
+	"github.com/hibiken/asynq"
+)
+
+var (
+	// ErrCallWillRevert is returned when a pre-sign simulation shows that the contract call would revert on chain.
+	ErrCallWillRevert = errors.New("simulate: call will revert")
+	// ErrVoucherNotAllowed is returned when a voucher was removed from the allowlist after the request was accepted.
+	ErrVoucherNotAllowed = errors.New("voucher not allowed")
+)
+
+// simulateTx runs eth_call and eth_estimateGas for a contract call before it is signed.
+// It returns the estimated gas with the configured safety margin added as the gas limit.
+// Calls that revert or return false (ERC20 convention for a failed transfer/approve) fail with ErrCallWillRevert.
+// Tokens which return nothing from transfer/approve (e.g. USDT) succeed unless they revert, the same as with SafeERC20.
+func simulateTx(
+	ctx context.Context,
+	cu *custodial.Custodial,
+	from common.Address,
+	contract common.Address,
+	input []byte,
+	fn *w3.Func,
+) (uint64, error) {
+	var (
+		callErrs    w3.CallErrors
+		estimateGas uint64
+		output      []byte
+	)
+
+	msg := &w3types.Message{
+		From:  from,
+		To:    &contract,
+		Input: input,
+	}
+
+	if err := cu.CeloProvider.Client.CallCtx(
+		ctx,
+		eth.Call(msg, nil, nil).Returns(&output),
+		eth.EstimateGas(msg, nil).Returns(&estimateGas),
+	); err != nil {
+		// Only execution reverts are final, any other per call error (e.g. a lagging node) is retried.
+		if errors.As(err, &callErrs) {
+			for _, callErr := range callErrs {
+				if isExecutionReverted(callErr) {
+					return 0, fmt.Errorf("%w: %v", ErrCallWillRevert, callErr)
+				}
+			}
+		}
+		return 0, err
+	}
+
+	if err := checkReturnData(fn, output); err != nil {
+		return 0, err
+	}
+
+	return estimateGas + estimateGas*uint64(cu.GasLimitMargin)/100, nil
+}
+
+// checkReturnData checks the return data of a successful eth_call, only return data that is present is decoded.
+func checkReturnData(fn *w3.Func, output []byte) error {
+	var (
+		success bool
+	)
+
+	if len(output) == 0 {
+		return nil
+	}
+
+	if err := fn.DecodeReturns(output, &success); err != nil {
+		return fmt.Errorf("%w: undecodable return data: %v", ErrCallWillRevert, err)
+	}
+
+	if !success {
+		return fmt.Errorf("%w: call returned false", ErrCallWillRevert)
+	}
+
+	return nil
+}
+
+// isExecutionReverted checks whether a JSON-RPC call error is an EVM execution revert.
+// Nodes report a revert with the execution reverted message and attach the revert reason as error data when there is one.
+func isExecutionReverted(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if strings.Contains(err.Error(), vm.ErrExecutionReverted.Error()) {
+		return true
+	}
+
+	var dataErr rpc.DataError
+	return errors.As(err, &dataErr) && dataErr.ErrorData() != nil
+}
+
+// recordSignFailure persists the reason a request could not be signed against its tracking id.
+// The returned error skips retries since the request would fail again with the same input.
+func recordSignFailure(
+	ctx context.Context,
+	cu *custodial.Custodial,
+	trackingId string,
+	otxType enum.OtxType,
+	from string,
+	reason error,
+) error {
+	if err := cu.Store.CreateSignFailure(ctx, trackingId, otxType, from, reason.Error()); err != nil {
+		return err
+	}
+
+	notifyTaskOtxEvent(ctx, cu, pub.OtxRejectedSubject, pub.OtxEvent{
+		TrackingId: trackingId,
+		Type:       string(otxType),
+		From:       from,
+		Status:     string(enum.REJECTED),
+		Reason:     reason.Error(),
+	})
+
+	return fmt.Errorf("%w: %w", reason, asynq.SkipRetry)
+}
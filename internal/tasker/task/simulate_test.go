@@ -0,0 +1,38 @@
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/celo-org/celo-blockchain/common"
+	"github.com/grassrootseconomics/w3-celo-patch"
+)
+
+func TestCheckReturnData(t *testing.T) {
+	transfer := w3.MustNewFunc("transfer(address,uint256)", "bool")
+
+	tests := []struct {
+		name       string
+		output     []byte
+		wantRevert bool
+	}{
+		{name: "true", output: common.LeftPadBytes([]byte{1}, 32)},
+		{name: "no return data", output: nil},
+		{name: "false", output: make([]byte, 32), wantRevert: true},
+		{name: "short return data", output: []byte{1}, wantRevert: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReturnData(transfer, tt.output)
+			if errors.Is(err, ErrCallWillRevert) != tt.wantRevert {
+				t.Fatalf("got error %v, want revert %v", err, tt.wantRevert)
+			}
+
+			if !tt.wantRevert && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
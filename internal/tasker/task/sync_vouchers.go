@@ -0,0 +1,21 @@
+package task
+
+import (
+	"context"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/hibiken/asynq"
+)
+
+// SyncVouchersProcessor periodically rebuilds the voucher allowlist from the token index and the manually allowlisted vouchers.
+func SyncVouchersProcessor(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+		count, err := cu.SyncVoucherAllowlist(ctx)
+		if err != nil {
+			return err
+		}
+
+		cu.Logg.Debug("sync_vouchers: voucher allowlist synced", "count", count)
+		return nil
+	}
+}
@@ -0,0 +1,167 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/google/uuid"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
+	"github.com/grassrootseconomics/w3-celo-patch/w3types"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	balanceMonitorActor = "balance_monitor"
+)
+
+var (
+	// balanceGauges holds the last observed CELO balance for each balance gauge name.
+	balanceGauges sync.Map
+)
+
+// SystemBalanceProcessor fetches the CELO balance of every system pool account and the gas faucet contract.
+// Balances are exported on /metrics as custodial_system_balance{account="..."} and custodial_gas_faucet_balance.
+// Registration is paused or the system global lock engaged when balances fall below the configured thresholds.
+func SystemBalanceProcessor(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var (
+			faucetBalanceWei big.Int
+			balances         = make([]big.Int, len(cu.SystemPool))
+			calls            = make([]w3types.Caller, len(cu.SystemPool)+1)
+		)
+
+		for i, systemAccount := range cu.SystemPool {
+			calls[i] = eth.Balance(celoutils.HexToAddress(systemAccount), nil).Returns(&balances[i])
+		}
+		calls[len(cu.SystemPool)] = eth.Balance(cu.RegistryMap[celoutils.GasFaucet], nil).Returns(&faucetBalanceWei)
+
+		if err := cu.CeloProvider.Client.CallCtx(ctx, calls...); err != nil {
+			return err
+		}
+
+		var (
+			lowestAccount string
+			lowestBalance float64
+		)
+
+		for i, systemAccount := range cu.SystemPool {
+			balance := weiToCelo(&balances[i])
+			setBalanceGauge(fmt.Sprintf(`custodial_system_balance{account=%q}`, systemAccount), balance)
+
+			if lowestAccount == "" || balance < lowestBalance {
+				lowestAccount = systemAccount
+				lowestBalance = balance
+			}
+
+			if balance < cu.Thresholds.WarnSystemBalance {
+				cu.Logg.Warn("system_balance: system account balance low", "system_account", systemAccount, "balance", balance)
+			}
+		}
+
+		faucetBalance := weiToCelo(&faucetBalanceWei)
+		setBalanceGauge("custodial_gas_faucet_balance", faucetBalance)
+
+		if cu.Thresholds.LockSystemBalance > 0 && lowestBalance < cu.Thresholds.LockSystemBalance {
+			if err := setSystemLock(ctx, cu, custodial.LockGlobal, true, pub.AlertEvent{
+				Action:    pub.AlertGlobalLock,
+				Account:   lowestAccount,
+				Balance:   lowestBalance,
+				Threshold: cu.Thresholds.LockSystemBalance,
+			}); err != nil {
+				return err
+			}
+		}
+
+		alert := pub.AlertEvent{
+			Action:  pub.AlertRegistrationResumed,
+			Account: lowestAccount,
+			Balance: lowestBalance,
+		}
+		if cu.Thresholds.PauseSystemBalance > 0 && lowestBalance < cu.Thresholds.PauseSystemBalance {
+			alert.Action = pub.AlertRegistrationPaused
+			alert.Threshold = cu.Thresholds.PauseSystemBalance
+		} else if cu.Thresholds.PauseFaucetBalance > 0 && faucetBalance < cu.Thresholds.PauseFaucetBalance {
+			alert.Action = pub.AlertRegistrationPaused
+			alert.Account = cu.RegistryMap[celoutils.GasFaucet].Hex()
+			alert.Balance = faucetBalance
+			alert.Threshold = cu.Thresholds.PauseFaucetBalance
+		}
+
+		return setSystemLock(ctx, cu, custodial.LockRegistration, alert.Action == pub.AlertRegistrationPaused, alert)
+	}
+}
+
+// setSystemLock sets a system lock and publishes the alert only if the lock state flips.
+// The monitor only releases locks it engaged itself.
+// The alert is published before the lock is set so that a failed publish is retried by the next run with the lock state unchanged.
+// The alert can then be published twice if setting the lock fails after it was published.
+func setSystemLock(ctx context.Context, cu *custodial.Custodial, scope string, locked bool, alert pub.AlertEvent) error {
+	current, err := cu.IsLocked(ctx, scope)
+	if err != nil {
+		return err
+	}
+
+	if current == locked {
+		return nil
+	}
+
+	// A lock engaged through the admin API is only ever released through the admin API.
+	if !locked {
+		setBy, err := cu.LockSetBy(ctx, scope)
+		if err != nil {
+			return err
+		}
+
+		if setBy != balanceMonitorActor {
+			return nil
+		}
+	}
+
+	reason := fmt.Sprintf("%s balance %f recovered", alert.Account, alert.Balance)
+	if locked {
+		reason = fmt.Sprintf("%s balance %f below %f", alert.Account, alert.Balance, alert.Threshold)
+	}
+
+	alert.Timestamp = time.Now().Unix()
+	if err := cu.Pub.Publish(pub.AlertSubject, uuid.NewString(), alert); err != nil {
+		return err
+	}
+
+	changed, err := cu.SetLock(ctx, custodial.LockChange{
+		Scope:  scope,
+		Locked: locked,
+		Reason: reason,
+		SetBy:  balanceMonitorActor,
+	})
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		cu.Logg.Warn("system_balance: system lock changed", "scope", scope, "locked", locked, "action", alert.Action, "account", alert.Account, "balance", alert.Balance)
+	}
+
+	return nil
+}
+
+// setBalanceGauge registers the gauge on first use, later calls only update the value it reports.
+func setBalanceGauge(name string, balance float64) {
+	if _, loaded := balanceGauges.Swap(name, balance); !loaded {
+		metrics.GetOrCreateGauge(name, func() float64 {
+			v, _ := balanceGauges.Load(name)
+			return v.(float64)
+		})
+	}
+}
+
+func weiToCelo(wei *big.Int) float64 {
+	celo, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return celo
+}
@@ -0,0 +1,56 @@
+package task
+
+import (
+	"context"
+
+	"github.com/bsm/redislock"
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+)
+
+const systemPoolCursorKey = "system:pool_cursor"
+
+// obtainSystemSigner picks a system account from the signer pool and locks it.
+// Accounts are tried round-robin from a cursor shared across replicas and the first one not locked by another task is used.
+// If every account is busy, it waits on the account at the cursor.
+func obtainSystemSigner(ctx context.Context, cu *custodial.Custodial) (string, *redislock.Lock, error) {
+	var (
+		start int
+	)
+
+	if len(cu.SystemPool) > 1 {
+		cursor, err := cu.RedisClient.Incr(ctx, systemPoolCursorKey).Result()
+		if err != nil {
+			return "", nil, err
+		}
+		start = int(cursor % int64(len(cu.SystemPool)))
+
+		for i := range cu.SystemPool {
+			systemAccount := cu.SystemPool[(start+i)%len(cu.SystemPool)]
+
+			lock, err := cu.LockProvider.Obtain(ctx, lockPrefix+systemAccount, lockTimeout, nil)
+			if err == nil {
+				return systemAccount, lock, nil
+			}
+
+			if err != redislock.ErrNotObtained {
+				return "", nil, err
+			}
+		}
+	}
+
+	systemAccount := cu.SystemPool[start]
+
+	lock, err := cu.LockProvider.Obtain(
+		ctx,
+		lockPrefix+systemAccount,
+		lockTimeout,
+		&redislock.Options{
+			RetryStrategy: lockRetry(),
+		},
+	)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return systemAccount, lock, nil
+}
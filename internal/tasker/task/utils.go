@@ -1,10 +1,14 @@
 package task
 
 import (
+	"errors"
 	"math/big"
 	"time"
 
 	"github.com/bsm/redislock"
+	ethereum "github.com/celo-org/celo-blockchain"
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/w3-celo-patch"
 )
 
 const (
@@ -13,15 +17,11 @@ const (
 	lockTimeout    = 1 * time.Second
 )
 
-var (
-	// 20 gwei = max gas price we are willing to pay
-	// 250k = max gas limit
-	// minGasBalanceRequired is optimistic that the immidiate next transfer request will be successful
+const (
+	// minGasBalanceTxCount is the number of max gas limit txs an account should be able to pay for at the current gas fee cap.
+	// The threshold is optimistic that the immidiate next transfer request will be successful
 	// but the subsequent one could fail (though low probability), therefore we can trigger a gas lock.
-	// Therefore our system wide threshold is 0.01 CELO or 10000000000000000 gas units
-	// UPDATE: Feb 2025
-	// 0.04455 CELO
-	minGasBalanceRequired = big.NewInt(27000000000 * 550000 * 3)
+	minGasBalanceTxCount = 3
 )
 
 // lockRetry will at most try to obtain the lock 20 times within ~0.5s.
@@ -33,7 +33,39 @@ func lockRetry() redislock.RetryStrategy {
 	)
 }
 
+// minGasBalanceRequired is the gas lock threshold at the current gas fee cap.
+func minGasBalanceRequired(gasFeeCap *big.Int) *big.Int {
+	minBalance := new(big.Int).Mul(gasFeeCap, big.NewInt(int64(celoutils.SafeGasLimit)))
+	return minBalance.Mul(minBalance, big.NewInt(minGasBalanceTxCount))
+}
+
 // balanceCheck compares the network balance with the system set min as threshold to execute a transfer.
-func balanceCheck(networkBalance big.Int) bool {
-	return minGasBalanceRequired.Cmp(&networkBalance) < 0
+func balanceCheck(networkBalance big.Int, gasFeeCap *big.Int) bool {
+	return minGasBalanceRequired(gasFeeCap).Cmp(&networkBalance) < 0
+}
+
+// bumpGas increases a gas price by the given percentage.
+// It rounds up so that small values (e.g. a 5 wei tip) still increase.
+func bumpGas(gasPrice *big.Int, percent int64) *big.Int {
+	bumped := new(big.Int).Mul(gasPrice, big.NewInt(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// isNotFound checks whether an RPC call failed because the requested resource (e.g. a receipt) does not exist.
+// w3 reports a null result per call with an unexported error carrying the same message as ethereum.NotFound.
+func isNotFound(err error) bool {
+	var callErrs w3.CallErrors
+	if !errors.As(err, &callErrs) {
+		return errors.Is(err, ethereum.NotFound)
+	}
+
+	for _, callErr := range callErrs {
+		if callErr != nil && (errors.Is(callErr, ethereum.NotFound) || callErr.Error() == ethereum.NotFound.Error()) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,220 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/custodial"
+	"github.com/grassrootseconomics/cic-custodial/internal/pub"
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+	"github.com/grassrootseconomics/cic-custodial/internal/tasker"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	webhookMaxRetry = 12
+	webhookTimeout  = 10 * time.Second
+
+	WebhookSignatureHeader = "X-Custodial-Signature"
+	WebhookTimestampHeader = "X-Custodial-Timestamp"
+)
+
+var (
+	ErrCallbackUrlNotAllowed = errors.New("webhook: callback url must be a public http(s) url")
+
+	// webhookClient only connects to public addresses, the check runs on the resolved address so DNS can't route a
+	// callback url to loopback, link-local (cloud metadata) or private ranges. Redirects are checked the same way.
+	webhookClient = &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			// An env proxy would make the connection on our behalf and bypass the address check.
+			Proxy: nil,
+			DialContext: (&net.Dialer{
+				Timeout: webhookTimeout,
+				Control: webhookDialControl,
+			}).DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+		},
+	}
+
+	// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), it is not covered by netip.Addr.IsPrivate.
+	sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+// CheckCallbackUrl rejects callback urls which are not http(s) or point at a host the webhook client won't connect to.
+// Host names are resolved by the webhook client on every delivery and checked there.
+func CheckCallbackUrl(callbackUrl string) error {
+	u, err := url.Parse(callbackUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrCallbackUrlNotAllowed
+	}
+
+	if u.Hostname() == "localhost" {
+		return ErrCallbackUrlNotAllowed
+	}
+
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !isPublicAddr(addr) {
+		return ErrCallbackUrlNotAllowed
+	}
+
+	return nil
+}
+
+// webhookDialControl rejects a connection to a non public address after the host is resolved.
+func webhookDialControl(_ string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrCallbackUrlNotAllowed, addrPort.Addr())
+	}
+
+	return nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+type WebhookPayload struct {
+	TrackingId string `json:"trackingId"`
+	// Event is the body POSTed to the callback url.
+	Event pub.StatusEvent `json:"event"`
+}
+
+// enqueueWebhook queues the delivery of an otx lifecycle event if a callback url was registered for its tracking id.
+// A lifecycle event is only queued once per tx hash.
+func enqueueWebhook(ctx context.Context, cu *custodial.Custodial, event pub.StatusEvent) error {
+	if _, err := cu.Store.GetWebhook(ctx, event.TrackingId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	webhookPayload, err := json.Marshal(WebhookPayload{
+		TrackingId: event.TrackingId,
+		Event:      event,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = cu.TaskerClient.CreateTask(
+		ctx,
+		tasker.WebhookTask,
+		tasker.DefaultPriority,
+		&tasker.Task{
+			Id:      fmt.Sprintf("webhook:%s:%s:%s", event.Event, event.TrackingId, event.TxHash),
+			Payload: webhookPayload,
+		},
+		asynq.MaxRetry(webhookMaxRetry),
+	)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+
+	return nil
+}
+
+// WebhookProcessor POSTs a lifecycle event to the callback url of its tracking id.
+// The body is signed with the client's webhook secret: hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// Every attempt is recorded, a non 2xx response is retried with backoff.
+func WebhookProcessor(cu *custodial.Custodial) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var (
+			payload WebhookPayload
+		)
+
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			return err
+		}
+
+		webhook, err := cu.Store.GetWebhook(ctx, payload.TrackingId)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("webhook: tracking id %s has no webhook: %w", payload.TrackingId, asynq.SkipRetry)
+			}
+			return err
+		}
+
+		body, err := json.Marshal(payload.Event)
+		if err != nil {
+			return err
+		}
+
+		retryCount, _ := asynq.GetRetryCount(ctx)
+		delivery := store.WebhookDelivery{
+			WebhookId: webhook.Id,
+			Event:     payload.Event.Event,
+			TxHash:    payload.Event.TxHash,
+			Attempt:   uint(retryCount) + 1,
+		}
+
+		deliveryErr := deliverWebhook(ctx, webhook, body, &delivery)
+		if deliveryErr != nil {
+			delivery.Error = deliveryErr.Error()
+		}
+
+		if err := cu.Store.CreateWebhookDelivery(ctx, delivery); err != nil {
+			return err
+		}
+
+		// The callback host won't become public on a retry.
+		if errors.Is(deliveryErr, ErrCallbackUrlNotAllowed) {
+			return fmt.Errorf("%w: %w", deliveryErr, asynq.SkipRetry)
+		}
+
+		return deliveryErr
+	}
+}
+
+func deliverWebhook(ctx context.Context, webhook store.Webhook, body []byte, delivery *store.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.CallbackUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	delivery.StatusCode = &resp.StatusCode
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: callback responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
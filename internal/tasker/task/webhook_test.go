@@ -0,0 +1,68 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grassrootseconomics/cic-custodial/internal/store"
+)
+
+func TestCheckCallbackUrl(t *testing.T) {
+	tests := []struct {
+		callbackUrl string
+		wantErr     bool
+	}{
+		{callbackUrl: "https://example.com/callback"},
+		{callbackUrl: "http://203.0.113.10:8080/callback"},
+		{callbackUrl: "ftp://example.com/callback", wantErr: true},
+		{callbackUrl: "https:///callback", wantErr: true},
+		{callbackUrl: "http://localhost:8080/callback", wantErr: true},
+		{callbackUrl: "http://127.0.0.1/callback", wantErr: true},
+		{callbackUrl: "http://[::1]/callback", wantErr: true},
+		{callbackUrl: "http://[::ffff:127.0.0.1]/callback", wantErr: true},
+		{callbackUrl: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{callbackUrl: "http://10.0.0.5/callback", wantErr: true},
+		{callbackUrl: "http://172.16.0.1/callback", wantErr: true},
+		{callbackUrl: "http://192.168.1.1/callback", wantErr: true},
+		{callbackUrl: "http://100.64.0.1/callback", wantErr: true},
+		{callbackUrl: "http://[fd00::1]/callback", wantErr: true},
+		{callbackUrl: "http://0.0.0.0/callback", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.callbackUrl, func(t *testing.T) {
+			if err := CheckCallbackUrl(tt.callbackUrl); (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeliverWebhookRejectsLoopback(t *testing.T) {
+	var delivered bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = true
+	}))
+	t.Cleanup(server.Close)
+
+	// A host name resolving to loopback is only caught when dialing.
+	callbackUrl := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	err := deliverWebhook(context.Background(), store.Webhook{
+		CallbackUrl: callbackUrl,
+		Secret:      "secret",
+	}, []byte("{}"), &store.WebhookDelivery{})
+	if !errors.Is(err, ErrCallbackUrlNotAllowed) {
+		t.Fatalf("got error %v, want %v", err, ErrCallbackUrlNotAllowed)
+	}
+
+	if delivered {
+		t.Fatal("webhook delivered to loopback")
+	}
+}
@@ -20,6 +20,13 @@ const (
 	SignTransferTask     TaskName = "usr:sign_transfer"
 	SignTransferTaskAuth TaskName = "usr:sign_transfer_auth"
 	DispatchTxTask       TaskName = "rpc:dispatch"
+	ScanStuckTxTask      TaskName = "sys:scan_stuck_tx"
+	ReplaceTxTask        TaskName = "sys:replace_tx"
+	ReconcileNonceTask   TaskName = "sys:reconcile_nonce"
+	SystemBalanceTask    TaskName = "sys:system_balance"
+	WebhookTask          TaskName = "usr:webhook"
+	SyncVouchersTask     TaskName = "sys:sync_vouchers"
+	PublishEventTask     TaskName = "sys:publish_event"
 )
 
 const (
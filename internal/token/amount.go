@@ -0,0 +1,58 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount = errors.New("amount must be a non-negative decimal number e.g. 12.5")
+
+	decimalAmountRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// ParseAmount converts a decimal amount (e.g. "12.5") to base units of the voucher.
+// The conversion is exact, an amount with more decimal places than the voucher supports is rejected rather than rounded.
+func (m Metadata) ParseAmount(amount string) (*big.Int, error) {
+	if !decimalAmountRegex.MatchString(amount) {
+		return nil, ErrInvalidAmount
+	}
+
+	integerPart, fractionalPart, _ := strings.Cut(amount, ".")
+	fractionalPart = strings.TrimRight(fractionalPart, "0")
+
+	if len(fractionalPart) > int(m.Decimals) {
+		return nil, fmt.Errorf("amount has more than %d decimal places supported by %s", m.Decimals, m.Symbol)
+	}
+
+	baseUnits, ok := new(big.Int).SetString(integerPart+fractionalPart+strings.Repeat("0", int(m.Decimals)-len(fractionalPart)), 10)
+	if !ok {
+		return nil, ErrInvalidAmount
+	}
+
+	return baseUnits, nil
+}
+
+// FormatAmount converts base units of the voucher to a decimal amount without trailing zeros.
+func (m Metadata) FormatAmount(baseUnits *big.Int) string {
+	digits := baseUnits.String()
+	if m.Decimals == 0 {
+		return digits
+	}
+
+	if len(digits) <= int(m.Decimals) {
+		digits = strings.Repeat("0", int(m.Decimals)-len(digits)+1) + digits
+	}
+
+	integerPart := digits[:len(digits)-int(m.Decimals)]
+	fractionalPart := strings.TrimRight(digits[len(digits)-int(m.Decimals):], "0")
+
+	if fractionalPart == "" {
+		return integerPart
+	}
+
+	return integerPart + "." + fractionalPart
+}
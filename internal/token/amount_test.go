@@ -0,0 +1,111 @@
+package token
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		decimals  uint8
+		amount    string
+		want      string
+		wantErr   bool
+		wantErrIs error
+	}{
+		{name: "fraction", decimals: 6, amount: "12.5", want: "12500000"},
+		{name: "integer", decimals: 6, amount: "12", want: "12000000"},
+		{name: "zero", decimals: 6, amount: "0", want: "0"},
+		{name: "smallest unit", decimals: 6, amount: "0.000001", want: "1"},
+		{name: "leading zeros", decimals: 6, amount: "007.5", want: "7500000"},
+		{name: "leading zeros only", decimals: 6, amount: "000", want: "0"},
+		{name: "trailing zeros", decimals: 6, amount: "1.500000", want: "1500000"},
+		{name: "trailing zeros past decimals", decimals: 6, amount: "1.50000000000", want: "1500000"},
+		{name: "more places than decimals", decimals: 6, amount: "1.0000001", wantErr: true},
+		{name: "0 decimals integer", decimals: 0, amount: "12", want: "12"},
+		{name: "0 decimals trailing zeros", decimals: 0, amount: "12.000", want: "12"},
+		{name: "0 decimals fraction", decimals: 0, amount: "12.5", wantErr: true},
+		{name: "18 decimals", decimals: 18, amount: "1234567890.123456789012345678", want: "1234567890123456789012345678"},
+		{name: "empty", decimals: 6, amount: "", wantErrIs: ErrInvalidAmount},
+		{name: "negative", decimals: 6, amount: "-1", wantErrIs: ErrInvalidAmount},
+		{name: "no integer part", decimals: 6, amount: ".5", wantErrIs: ErrInvalidAmount},
+		{name: "no fractional part", decimals: 6, amount: "1.", wantErrIs: ErrInvalidAmount},
+		{name: "exponent", decimals: 6, amount: "1e3", wantErrIs: ErrInvalidAmount},
+		{name: "whitespace", decimals: 6, amount: " 1", wantErrIs: ErrInvalidAmount},
+		{name: "comma separator", decimals: 6, amount: "1,5", wantErrIs: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Metadata{Symbol: "TEST", Decimals: tt.decimals}.ParseAmount(tt.amount)
+
+			if tt.wantErr || tt.wantErrIs != nil {
+				if err == nil {
+					t.Fatalf("got %s, want error", got)
+				}
+
+				if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErrIs)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.String() != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		decimals  uint8
+		baseUnits string
+		want      string
+	}{
+		{name: "fraction", decimals: 6, baseUnits: "12500000", want: "12.5"},
+		{name: "integer", decimals: 6, baseUnits: "12000000", want: "12"},
+		{name: "zero", decimals: 6, baseUnits: "0", want: "0"},
+		{name: "smallest unit", decimals: 6, baseUnits: "1", want: "0.000001"},
+		{name: "less than one", decimals: 6, baseUnits: "999999", want: "0.999999"},
+		{name: "exactly decimals digits", decimals: 6, baseUnits: "100000", want: "0.1"},
+		{name: "0 decimals", decimals: 0, baseUnits: "12", want: "12"},
+		{name: "0 decimals zero", decimals: 0, baseUnits: "0", want: "0"},
+		{name: "uint256 max", decimals: 18, baseUnits: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)).String(), want: "115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			baseUnits, ok := new(big.Int).SetString(tt.baseUnits, 10)
+			if !ok {
+				t.Fatalf("invalid base units %s", tt.baseUnits)
+			}
+
+			metadata := Metadata{Symbol: "TEST", Decimals: tt.decimals}
+
+			got := metadata.FormatAmount(baseUnits)
+			if got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+
+			// Formatting is exact, the formatted amount parses back to the same base units.
+			parsed, err := metadata.ParseAmount(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if parsed.Cmp(baseUnits) != 0 {
+				t.Fatalf("%s parsed back to %s, want %s", got, parsed, baseUnits)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/grassrootseconomics/celoutils"
+	"github.com/grassrootseconomics/w3-celo-patch"
+	"github.com/grassrootseconomics/w3-celo-patch/module/eth"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	metadataCacheKeyPrefix = "token_metadata:"
+)
+
+var (
+	decimalsFunc = w3.MustNewFunc("decimals()", "uint8")
+	nameFunc     = w3.MustNewFunc("name()", "string")
+	symbolFunc   = w3.MustNewFunc("symbol()", "string")
+)
+
+type (
+	Opts struct {
+		CacheTTL      time.Duration
+		ChainProvider *celoutils.Provider
+		RedisClient   *redis.Client
+	}
+
+	// MetadataCache fetches ERC20 voucher metadata from the network and caches it across all replicas.
+	MetadataCache struct {
+		cacheTTL      time.Duration
+		chainProvider *celoutils.Provider
+		redisClient   *redis.Client
+	}
+
+	Metadata struct {
+		Address  string `json:"address"`
+		Name     string `json:"name"`
+		Symbol   string `json:"symbol"`
+		Decimals uint8  `json:"decimals"`
+	}
+)
+
+func NewMetadataCache(o Opts) *MetadataCache {
+	return &MetadataCache{
+		cacheTTL:      o.CacheTTL,
+		chainProvider: o.ChainProvider,
+		redisClient:   o.RedisClient,
+	}
+}
+
+// Metadata returns the cached metadata of a voucher or fetches it from the voucher contract.
+func (m *MetadataCache) Metadata(ctx context.Context, voucherAddress string) (Metadata, error) {
+	var (
+		metadata Metadata
+	)
+
+	cachedMetadata, err := m.redisClient.Get(ctx, metadataCacheKeyPrefix+voucherAddress).Bytes()
+	if err == nil {
+		if err := json.Unmarshal(cachedMetadata, &metadata); err != nil {
+			return metadata, err
+		}
+
+		return metadata, nil
+	} else if !errors.Is(err, redis.Nil) {
+		return metadata, err
+	}
+
+	metadata.Address = voucherAddress
+	contractAddress := celoutils.HexToAddress(voucherAddress)
+
+	if err := m.chainProvider.Client.CallCtx(
+		ctx,
+		eth.CallFunc(decimalsFunc, contractAddress).Returns(&metadata.Decimals),
+		eth.CallFunc(nameFunc, contractAddress).Returns(&metadata.Name),
+		eth.CallFunc(symbolFunc, contractAddress).Returns(&metadata.Symbol),
+	); err != nil {
+		return metadata, err
+	}
+
+	metadataValue, err := json.Marshal(metadata)
+	if err != nil {
+		return metadata, err
+	}
+
+	if err := m.redisClient.Set(ctx, metadataCacheKeyPrefix+voucherAddress, metadataValue, m.cacheTTL).Err(); err != nil {
+		return metadata, err
+	}
+
+	return metadata, nil
+}
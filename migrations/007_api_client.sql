@@ -0,0 +1,21 @@
+-- API client table
+-- Each client (frontend) authenticates with its own API key, only the sha256 hash of the key is stored
+-- e.g. INSERT INTO api_client("name", key_hash, scopes) VALUES('ussd', encode(sha256('<api_key>'), 'hex'), '{account:create,sign:transfer,track:read}');
+CREATE TABLE IF NOT EXISTS api_client (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    "name" TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+create trigger update_api_client_timestamp
+    before update on api_client
+for each row
+execute procedure update_timestamp();
+
+-- Record the API client which caused the otx
+ALTER TABLE otx_sign
+ADD COLUMN client_id INT REFERENCES api_client(id);
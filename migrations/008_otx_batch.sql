@@ -0,0 +1,10 @@
+-- Otx batch table
+-- Groups the tracking ids of transfers submitted together in a single batch request
+CREATE TABLE IF NOT EXISTS otx_batch (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    batch_id uuid NOT NULL,
+    tracking_id uuid NOT NULL,
+    client_id INT REFERENCES api_client(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS batch_id_idx ON otx_batch (batch_id);
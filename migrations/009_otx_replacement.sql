@@ -0,0 +1,6 @@
+-- A replacement otx re-signs the same nonce and calldata with a bumped gas fee
+-- The replaced otx is marked as OBSOLETE
+ALTER TABLE otx_sign
+ADD COLUMN replaces INT REFERENCES otx_sign(id);
+CREATE INDEX IF NOT EXISTS replaces_idx ON otx_sign (replaces);
+CREATE INDEX IF NOT EXISTS otx_id_idx ON otx_dispatch (otx_id);
@@ -0,0 +1,11 @@
+-- Sign failure table
+-- Records requests rejected by a task processor before an otx could be signed e.g. a reverting pre-sign simulation
+CREATE TABLE IF NOT EXISTS otx_sign_failure (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    tracking_id uuid NOT NULL,
+    "type" TEXT REFERENCES otx_tx_type(value) NOT NULL,
+    "from" TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS sign_failure_tracking_id_idx ON otx_sign_failure (tracking_id);
@@ -0,0 +1,14 @@
+-- Nonce table
+-- Backs the postgres noncestore, next_nonce is the nonce that will be handed out on the next acquire
+CREATE TABLE IF NOT EXISTS nonce (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    public_key TEXT NOT NULL UNIQUE,
+    next_nonce BIGINT NOT NULL CHECK (next_nonce >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+create trigger update_nonce_timestamp
+    before update on nonce
+for each row
+execute procedure update_timestamp();
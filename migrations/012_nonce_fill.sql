@@ -0,0 +1,2 @@
+-- A zero value self transfer signed to fill a nonce gap which has no otx
+INSERT INTO otx_tx_type (value) VALUES ('NONCE_FILL');
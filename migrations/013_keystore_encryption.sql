@@ -0,0 +1,6 @@
+-- Envelope encryption of private keys at rest
+-- private_key holds the hex AES-GCM ciphertext, data_key the hex data key wrapped with master key key_version
+-- A NULL key_version indicates a legacy plaintext hex private key
+ALTER TABLE keystore
+ADD COLUMN data_key TEXT,
+ADD COLUMN key_version INT;
@@ -0,0 +1,3 @@
+-- Keys of accounts created through a remote signer are never stored
+ALTER TABLE keystore
+ALTER COLUMN private_key DROP NOT NULL;
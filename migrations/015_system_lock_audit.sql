@@ -0,0 +1,12 @@
+-- System lock audit table
+-- Every change to a system lock (global, registration, sign) whether made through the admin API or by the balance monitor
+CREATE TABLE IF NOT EXISTS system_lock_audit (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    scope TEXT NOT NULL,
+    locked BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    set_by TEXT NOT NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS system_lock_audit_scope_idx ON system_lock_audit (scope, id);
@@ -0,0 +1,28 @@
+-- Webhook table
+-- Status changes of the otx signed under a tracking id are POSTed to the callback url
+CREATE TABLE IF NOT EXISTS webhook (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    tracking_id uuid NOT NULL UNIQUE,
+    callback_url TEXT NOT NULL,
+    client_id INT REFERENCES api_client(id) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Webhook delivery table
+-- Every delivery attempt with the callback response status code or the error
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    webhook_id INT REFERENCES webhook(id) NOT NULL,
+    "event" TEXT NOT NULL,
+    tx_hash TEXT NOT NULL DEFAULT '',
+    attempt INT NOT NULL,
+    status_code INT,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhook_id_idx ON webhook_delivery (webhook_id);
+
+-- Webhook payloads for a client are signed with its secret (HMAC-SHA256), a client without a secret can't register callback urls
+-- e.g. UPDATE api_client SET webhook_secret = '<secret>' WHERE "name" = 'ussd';
+ALTER TABLE api_client
+ADD COLUMN webhook_secret TEXT;
@@ -0,0 +1,26 @@
+-- Record the voucher of a transfer otx for per voucher policy usage
+ALTER TABLE otx_sign
+ADD COLUMN voucher_address TEXT;
+CREATE INDEX IF NOT EXISTS otx_from_created_at_idx ON otx_sign ("from", created_at);
+
+-- Transfer policy table
+-- A NULL public_key applies to every account and a NULL voucher_address to every voucher, a NULL limit is not enforced
+-- Only the most specific active policy applies: account and voucher, account, voucher, default
+-- e.g. INSERT INTO transfer_policy(max_per_transfer, max_per_day, max_transfers_per_hour) VALUES(100000000, 500000000, 20);
+CREATE TABLE IF NOT EXISTS transfer_policy (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    public_key TEXT,
+    voucher_address TEXT,
+    max_per_transfer BIGINT,
+    max_per_day BIGINT,
+    max_transfers_per_hour INT,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS transfer_policy_scope_idx ON transfer_policy (COALESCE(public_key, ''), COALESCE(voucher_address, '')) WHERE active = true;
+
+create trigger update_transfer_policy_timestamp
+    before update on transfer_policy
+for each row
+execute procedure update_timestamp();
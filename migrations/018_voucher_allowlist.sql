@@ -0,0 +1,9 @@
+-- Voucher allowlist table
+-- Manual additions to the vouchers synced from the token index contract, signing is only allowed against allowlisted vouchers
+CREATE TABLE IF NOT EXISTS voucher_allowlist (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    voucher_address TEXT NOT NULL UNIQUE,
+    reason TEXT NOT NULL DEFAULT '',
+    added_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+-- Token amounts are uint256, NUMERIC(78, 0) holds every uint256 value
+ALTER TABLE otx_sign
+ALTER COLUMN transfer_value TYPE NUMERIC(78, 0);
+
+ALTER TABLE transfer_policy
+ALTER COLUMN max_per_transfer TYPE NUMERIC(78, 0),
+ALTER COLUMN max_per_day TYPE NUMERIC(78, 0);
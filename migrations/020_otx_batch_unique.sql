@@ -0,0 +1,7 @@
+-- A tracking id is only ever part of a batch once
+DELETE FROM otx_batch a USING otx_batch b
+WHERE a.batch_id = b.batch_id AND a.tracking_id = b.tracking_id AND a.id > b.id;
+
+DROP INDEX IF EXISTS batch_id_idx;
+ALTER TABLE otx_batch
+ADD CONSTRAINT otx_batch_batch_id_tracking_id_key UNIQUE (batch_id, tracking_id);
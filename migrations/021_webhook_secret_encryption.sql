@@ -0,0 +1,6 @@
+-- Envelope encryption of webhook secrets at rest, with the same master keys as the keystore
+-- webhook_secret holds the hex AES-GCM ciphertext, webhook_secret_data_key the hex data key wrapped with master key webhook_secret_key_version
+-- A NULL webhook_secret_key_version indicates a legacy plaintext secret, set secrets with the admin set-webhook-secret command
+ALTER TABLE api_client
+ADD COLUMN webhook_secret_data_key TEXT,
+ADD COLUMN webhook_secret_key_version INT;
@@ -0,0 +1,5 @@
+-- Encrypted private keys are bound to their public_key as AES-GCM additional data
+-- so that a ciphertext copied onto another row fails to open instead of loading another account's key
+-- A FALSE key_bound indicates a legacy plaintext key or a key sealed before the binding, re-encrypt them with the admin encrypt-keystore command
+ALTER TABLE keystore
+ADD COLUMN key_bound BOOLEAN NOT NULL DEFAULT FALSE;
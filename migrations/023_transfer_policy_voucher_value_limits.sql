@@ -0,0 +1,7 @@
+-- Value limits are in voucher base units, summed across vouchers with different decimals they have no consistent meaning
+-- max_per_transfer and max_per_day are therefore only allowed on voucher specific policies
+-- Existing voucher agnostic policies with value limits are left in place but their value limits are no longer enforced,
+-- move them to a policy per voucher
+ALTER TABLE transfer_policy
+ADD CONSTRAINT transfer_policy_value_limits_voucher_check
+CHECK (voucher_address IS NOT NULL OR (max_per_transfer IS NULL AND max_per_day IS NULL)) NOT VALID;
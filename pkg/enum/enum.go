@@ -23,4 +23,15 @@ const (
 	REFILL_GAS       OtxType = "REFILL_GAS"
 	TRANSFER_AUTH    OtxType = "TRANSFER_AUTHORIZATION"
 	TRANSFER_VOUCHER OtxType = "TRANSFER_VOUCHER"
+	NONCE_FILL       OtxType = "NONCE_FILL"
+)
+
+// Derived statuses are computed at query time and are never inserted into the db.
+const (
+	// PENDING represents a queued request which has not yet been signed.
+	PENDING OtxStatus = "PENDING"
+	// SIGNED represents a signed otx which has not yet been dispatched.
+	SIGNED OtxStatus = "SIGNED"
+	// REJECTED represents a request which failed before it could be signed.
+	REJECTED OtxStatus = "REJECTED"
 )
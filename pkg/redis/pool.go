@@ -8,7 +8,8 @@ import (
 )
 
 const (
-	systemGlobalLockKey = "system:global_lock"
+	systemGlobalLockKey   = "system:global_lock"
+	registrationPausedKey = "system:registration_paused"
 )
 
 type (
@@ -41,6 +42,10 @@ func NewRedisPool(ctx context.Context, o RedisPoolOpts) (*RedisPool, error) {
 		return nil, err
 	}
 
+	if err := redisClient.SetNX(ctx, registrationPausedKey, false, 0).Err(); err != nil {
+		return nil, err
+	}
+
 	return &RedisPool{
 		Client: redisClient,
 	}, nil
@@ -1,13 +1,56 @@
 --name: write-key-pair
--- Save hex encoded private key
+-- Save hex encoded private key, it is empty if the key is held by a remote signer
 -- $1: public_key
 -- $2: private_key
-INSERT INTO keystore(public_key, private_key) VALUES($1, $2) RETURNING id
+-- $3: data_key
+-- $4: key_version
+-- $5: key_bound
+INSERT INTO keystore(public_key, private_key, data_key, key_version, key_bound) VALUES($1, NULLIF($2, ''), $3, $4, $5) RETURNING id
 
 --name: load-key-pair
 -- Load saved key pair
 -- $1: public_key
-SELECT private_key FROM keystore WHERE public_key=$1
+SELECT COALESCE(private_key, ''), data_key, key_version, key_bound FROM keystore WHERE public_key=$1
+
+--name: get-keystore-accounts
+-- Gets the keys along with the account state of the selected accounts
+-- $1: public_keys
+SELECT keystore.public_key, COALESCE(keystore.private_key, ''), keystore.data_key, keystore.key_version, keystore.key_bound, keystore.active, gas_lock.lock FROM keystore
+INNER JOIN gas_lock ON keystore.id = gas_lock.key_id
+WHERE keystore.public_key = ANY($1)
+ORDER BY keystore.id
+
+--name: import-key-pair
+-- Save an imported key pair, nothing is returned if the public key already exists
+-- $1: public_key
+-- $2: private_key
+-- $3: data_key
+-- $4: key_version
+-- $5: key_bound
+-- $6: active
+INSERT INTO keystore(public_key, private_key, data_key, key_version, key_bound, active)
+SELECT $1, $2, $3, $4, $5, $6 WHERE NOT EXISTS (
+    SELECT 1 FROM keystore WHERE public_key=$1
+) RETURNING id
+
+--name: set-gas-lock
+-- Sets the gas lock of an imported key
+-- $1: key_id
+-- $2: lock
+UPDATE gas_lock SET lock = $2 WHERE key_id=$1
+
+--name: get-unbound-keys
+-- Gets a batch of legacy plaintext or unbound private keys, rows locked by a concurrent run are skipped
+-- $1: limit
+SELECT id, public_key, private_key, data_key, key_version FROM keystore WHERE NOT key_bound AND private_key IS NOT NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+
+--name: encrypt-key
+-- Replaces a plaintext or unbound private key with its bound ciphertext
+-- $1: id
+-- $2: private_key
+-- $3: data_key
+-- $4: key_version
+UPDATE keystore SET private_key = $2, data_key = $3, key_version = $4, key_bound = TRUE WHERE id = $1 AND NOT key_bound
 
 --name: create-otx
 -- Create a new locally originating tx
@@ -19,8 +62,11 @@ SELECT private_key FROM keystore WHERE public_key=$1
 -- $6: data
 -- $7: gas_price
 -- $8: gas_limit
--- $9: transfer_value
+-- $9: transfer_value, a decimal string
 -- $10: nonce
+-- $11: client_id
+-- $12: replaces
+-- $13: voucher_address
 INSERT INTO otx_sign(
     tracking_id,
     "type",
@@ -31,20 +77,124 @@ INSERT INTO otx_sign(
     gas_price,
     gas_limit,
     transfer_value,
-    nonce
-) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id
+    nonce,
+    client_id,
+    replaces,
+    voucher_address
+) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, 0), NULLIF($12, 0), NULLIF($13, '')) RETURNING id
+
+--name: get-otx
+-- Gets a single otx along with its current dispatch status
+-- $1: id
+SELECT otx_sign.id, otx_sign.tracking_id, otx_sign.type, otx_sign.raw_tx, otx_sign.tx_hash, otx_sign.from, otx_sign.transfer_value::TEXT, otx_sign.nonce,
+COALESCE(otx_sign.client_id, 0), COALESCE(otx_sign.voucher_address, ''), otx_sign.created_at, COALESCE(otx_dispatch.status, 'SIGNED') FROM otx_sign
+LEFT JOIN otx_dispatch ON otx_sign.id = otx_dispatch.otx_id
+WHERE otx_sign.id=$1
+
+--name: get-approval-otx
+-- Gets a batch of approval otx's after a cursor for the sender backfill
+-- $1: after_id
+-- $2: limit
+SELECT id, raw_tx, "from" FROM otx_sign WHERE type = 'TRANSFER_AUTHORIZATION' AND id > $1 ORDER BY id LIMIT $2
+
+--name: set-otx-sender
+-- Corrects the recorded sender of an otx
+-- $1: id
+-- $2: from
+UPDATE otx_sign SET "from" = $2 WHERE id = $1
+
+--name: get-stuck-otx
+-- Gets otx's which are candidates for a gas price bump replacement
+-- i.e. in network for longer than the stuck threshold or rejected for a low gas price,
+-- not yet replaced and whose nonce has not already been mined by another otx
+-- $1: stuck_after
+-- $2: limit
+SELECT otx_sign.id FROM otx_sign
+INNER JOIN otx_dispatch ON otx_sign.id = otx_dispatch.otx_id
+WHERE (
+    (otx_dispatch.status = 'IN_NETWORK' AND otx_dispatch.updated_at < CURRENT_TIMESTAMP - $1::interval)
+    OR otx_dispatch.status = 'FAIL_LOW_GAS_PRICE'
+)
+AND NOT EXISTS (
+    SELECT 1 FROM otx_sign replacement WHERE replacement.replaces = otx_sign.id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM otx_sign mined
+    INNER JOIN otx_dispatch mined_dispatch ON mined.id = mined_dispatch.otx_id
+    WHERE mined.from = otx_sign.from
+    AND mined.nonce = otx_sign.nonce
+    AND mined_dispatch.status IN ('SUCCESS', 'REVERTED')
+)
+ORDER BY otx_sign.id
+LIMIT $2
+
+--name: set-dispatch-status
+-- Overrides the dispatch status of an otx, creating it if the otx was never dispatched
+-- e.g. a replaced otx is marked as OBSOLETE and a re-dispatched otx as IN_NETWORK
+-- $1: otx_id
+-- $2: status
+WITH updated AS (
+    UPDATE otx_dispatch SET "status" = $2 WHERE otx_id=$1 RETURNING id
+)
+INSERT INTO otx_dispatch(otx_id, "status")
+SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM updated)
+
+--name: get-otx-id-by-nonce
+-- Gets the latest otx signed at a nonce, replacements are signed after the otx they replace
+-- $1: public_key
+-- $2: nonce
+SELECT id FROM otx_sign WHERE otx_sign.from = $1 AND nonce = $2 ORDER BY id DESC LIMIT 1
+
+--name: get-active-accounts
+-- Gets accounts which signed an otx within the window
+-- $1: window
+SELECT DISTINCT otx_sign.from FROM otx_sign WHERE created_at > CURRENT_TIMESTAMP - $1::interval
 
 --name: get-next-nonce
--- Gets last nonce from the otx table for a particular address for bootstrapping purposes
+-- Gets the highest nonce + 1 from the otx table for a particular address for bootstrapping purposes
+-- Replacements and nonce fills are signed later at lower nonces, so the latest otx is not necessarily the highest nonce
+-- $1: public_key
+SELECT nonce + 1 AS nonce FROM otx_sign WHERE otx_sign.from = $1 ORDER BY nonce DESC LIMIT 1;
+
+--name: get-next-dispatched-nonce
+-- Gets the highest nonce + 1 of the otx dispatched to the network for a particular address
+-- An otx that is signed but has no dispatch status yet is still queued for dispatch and is not a nonce gap
 -- $1: public_key
-SELECT nonce + 1 AS nonce FROM otx_sign WHERE otx_sign.from = $1 ORDER BY created_at DESC LIMIT 1;
+SELECT COALESCE(MAX(otx_sign.nonce) + 1, 0) AS nonce FROM otx_sign
+INNER JOIN otx_dispatch ON otx_sign.id = otx_dispatch.otx_id
+WHERE otx_sign.from = $1
 
 --name: get-tx-status-by-tracking-id
--- Gets tx status's from possible multiple txs with the same tracking_id
+-- Gets tx status's from possible multiple txs with the same tracking_id in the order they were signed
+-- An otx without a dispatch status is signed but not yet dispatched
 -- $1: tracking_id
-SELECT otx_sign.type, otx_sign.tx_hash, otx_sign.transfer_value, otx_sign.created_at, otx_dispatch.status FROM otx_sign
-INNER JOIN otx_dispatch ON otx_sign.id = otx_dispatch.otx_id
+SELECT otx_sign.id, otx_sign.type, otx_sign.tx_hash, otx_sign.transfer_value::TEXT AS transfer_value, otx_sign.voucher_address, otx_sign.created_at, otx_sign.from, otx_sign.nonce, otx_dispatch.block,
+COALESCE(otx_dispatch.status, 'SIGNED') AS status FROM otx_sign
+LEFT JOIN otx_dispatch ON otx_sign.id = otx_dispatch.otx_id
 WHERE otx_sign.tracking_id=$1
+ORDER BY otx_sign.id
+
+--name: get-account-transactions
+-- Gets the otx history of an address, newest first, paginated by otx_sign.id (keyset)
+-- Empty/null filters are ignored
+-- $1: from
+-- $2: type
+-- $3: status
+-- $4: created_at lower bound (inclusive)
+-- $5: created_at upper bound (exclusive)
+-- $6: cursor, the last otx_sign.id of the previous page
+-- $7: limit
+SELECT otx_sign.id, otx_sign.tracking_id, otx_sign.type, otx_sign.tx_hash, otx_sign.transfer_value::TEXT AS transfer_value, otx_sign.voucher_address, otx_sign.created_at, otx_sign.from, otx_sign.nonce, otx_dispatch.block,
+COALESCE(otx_dispatch.status, 'SIGNED') AS status FROM otx_sign
+LEFT JOIN otx_dispatch ON otx_sign.id = otx_dispatch.otx_id
+WHERE otx_sign.from = $1
+AND ($2::text = '' OR otx_sign.type = $2)
+AND ($3::text = '' OR COALESCE(otx_dispatch.status, 'SIGNED') = $3)
+AND ($4::timestamp IS NULL OR otx_sign.created_at >= $4)
+AND ($5::timestamp IS NULL OR otx_sign.created_at < $5)
+AND ($6::int = 0 OR otx_sign.id < $6)
+ORDER BY otx_sign.id DESC
+LIMIT $7
 
 --name: create-dispatch-status
 -- Create a new dispatch status
@@ -57,15 +207,49 @@ INSERT INTO otx_dispatch(
 
 --name: update-dispatch-status
 -- Updates the status of the dispatched tx with the chain mine status
+-- An OBSOLETE tx can still be mined if it wins the race against its replacement
 -- $1: tx_hash
 -- $2: status
 -- $3: block
-UPDATE otx_dispatch SET "status" = $2, "block" = $3 WHERE otx_dispatch.id = (
-    SELECT otx_dispatch.id FROM otx_dispatch
-    INNER JOIN otx_sign ON otx_dispatch.otx_id = otx_sign.id
-    WHERE otx_sign.tx_hash=$1
-    AND otx_dispatch.status = 'IN_NETWORK'
+-- Returns the updated otx, no rows if the tx is not ours or was already updated
+WITH updated AS (
+    UPDATE otx_dispatch SET "status" = $2, "block" = $3 WHERE otx_dispatch.id = (
+        SELECT otx_dispatch.id FROM otx_dispatch
+        INNER JOIN otx_sign ON otx_dispatch.otx_id = otx_sign.id
+        WHERE otx_sign.tx_hash=$1
+        AND otx_dispatch.status IN ('IN_NETWORK', 'OBSOLETE')
+    ) RETURNING otx_id
 )
+SELECT otx_sign.id, otx_sign.tracking_id, otx_sign."type", otx_sign.tx_hash, otx_sign."from", otx_sign.nonce
+FROM otx_sign INNER JOIN updated ON otx_sign.id = updated.otx_id
+
+--name: get-mined-otx
+-- Gets a mined otx and its mine status, e.g. to republish its events when a chain event is redelivered
+-- $1: tx_hash
+-- Returns no rows if the tx is not ours or not yet mined
+SELECT otx_sign.id, otx_sign.tracking_id, otx_sign."type", otx_sign.tx_hash, otx_sign."from", otx_sign.nonce, otx_dispatch.status
+FROM otx_sign INNER JOIN otx_dispatch ON otx_sign.id = otx_dispatch.otx_id
+WHERE otx_sign.tx_hash=$1 AND otx_dispatch.status IN ('SUCCESS', 'REVERTED')
+
+--name: create-sign-failure
+-- Records a request rejected before an otx could be signed
+-- $1: tracking_id
+-- $2: type
+-- $3: from
+-- $4: reason
+INSERT INTO otx_sign_failure(
+    tracking_id,
+    "type",
+    "from",
+    reason
+) VALUES($1, $2, $3, $4) RETURNING id
+
+--name: get-sign-failures-by-tracking-id
+-- Gets the requests rejected before signing under a tracking_id
+-- $1: tracking_id
+SELECT "type", "from", reason, created_at FROM otx_sign_failure
+WHERE tracking_id=$1
+ORDER BY id
 
 --name: activate-account
 -- Activate an account following successful quorum
@@ -93,4 +277,193 @@ UPDATE gas_lock SET lock = true WHERE key_id = (
 UPDATE gas_lock SET lock = false WHERE key_id = (
     SELECT id FROM keystore
     WHERE public_key=$1    
-)
\ No newline at end of file
+)
+
+--name: get-api-client-by-key-hash
+-- Gets an active API client by the sha256 hash of its API key
+-- $1: key_hash
+SELECT id, "name", scopes, webhook_secret IS NOT NULL FROM api_client WHERE key_hash=$1 AND active = true
+
+--name: set-webhook-secret
+-- Sets the webhook secret of an API client
+-- $1: name
+-- $2: webhook_secret
+-- $3: webhook_secret_data_key
+-- $4: webhook_secret_key_version
+UPDATE api_client SET webhook_secret = $2, webhook_secret_data_key = $3, webhook_secret_key_version = $4 WHERE "name" = $1
+
+--name: get-plaintext-webhook-secrets
+-- Gets every legacy plaintext webhook secret
+SELECT id, webhook_secret FROM api_client WHERE webhook_secret_key_version IS NULL AND webhook_secret IS NOT NULL FOR UPDATE
+
+--name: encrypt-webhook-secret
+-- Replaces a plaintext webhook secret with its ciphertext
+-- $1: id
+-- $2: webhook_secret
+-- $3: webhook_secret_data_key
+-- $4: webhook_secret_key_version
+UPDATE api_client SET webhook_secret = $2, webhook_secret_data_key = $3, webhook_secret_key_version = $4 WHERE id = $1 AND webhook_secret_key_version IS NULL
+
+--name: create-batch
+-- Groups the tracking ids of a batch request under a common batch id
+-- $1: batch_id
+-- $2: tracking_ids
+-- $3: client_id
+INSERT INTO otx_batch(batch_id, tracking_id, client_id)
+SELECT $1, unnest($2::uuid[]), NULLIF($3, 0)
+ON CONFLICT (batch_id, tracking_id) DO NOTHING
+
+--name: delete-batch-items
+-- Removes tracking ids which could not be queued from a batch
+-- $1: batch_id
+-- $2: tracking_ids
+DELETE FROM otx_batch WHERE batch_id=$1 AND tracking_id = ANY($2::uuid[])
+
+--name: get-batch-status
+-- Gets the transfer status of every tracking id in a batch
+-- A tracking id without an otx is either rejected or still queued and one without a dispatch status is signed but not yet dispatched
+-- Only the latest non OBSOLETE otx of a tracking id is joined so that replacements do not duplicate a transfer
+-- $1: batch_id
+SELECT otx_batch.tracking_id, latest.tx_hash, latest.transfer_value::TEXT AS transfer_value, latest.voucher_address,
+CASE
+    WHEN latest.id IS NULL AND EXISTS (
+        SELECT 1 FROM otx_sign_failure WHERE otx_sign_failure.tracking_id = otx_batch.tracking_id
+    ) THEN 'REJECTED'
+    WHEN latest.id IS NULL THEN 'PENDING'
+    WHEN latest.status IS NULL THEN 'SIGNED'
+    ELSE latest.status
+END AS status
+FROM otx_batch
+LEFT JOIN LATERAL (
+    SELECT otx_sign.id, otx_sign.tx_hash, otx_sign.transfer_value, otx_sign.voucher_address, otx_dispatch.status
+    FROM otx_sign
+    LEFT JOIN otx_dispatch ON otx_sign.id = otx_dispatch.otx_id
+    WHERE otx_sign.tracking_id = otx_batch.tracking_id AND otx_sign.type = 'TRANSFER_VOUCHER'
+    ORDER BY otx_dispatch.status IS NOT DISTINCT FROM 'OBSOLETE', otx_sign.id DESC
+    LIMIT 1
+) latest ON true
+WHERE otx_batch.batch_id=$1
+ORDER BY otx_batch.id
+
+--name: peek-nonce
+-- Gets the next nonce for an account
+-- $1: public_key
+SELECT next_nonce FROM nonce WHERE public_key=$1
+
+--name: lock-nonce
+-- Gets the next nonce for an account and locks the row until the end of the transaction
+-- $1: public_key
+SELECT next_nonce FROM nonce WHERE public_key=$1 FOR UPDATE
+
+--name: init-nonce
+-- Sets the next nonce for an account only if it has none
+-- $1: public_key
+-- $2: next_nonce
+INSERT INTO nonce(public_key, next_nonce) VALUES($1, $2)
+ON CONFLICT (public_key) DO NOTHING
+
+--name: set-nonce
+-- Sets the next nonce for an account
+-- $1: public_key
+-- $2: next_nonce
+INSERT INTO nonce(public_key, next_nonce) VALUES($1, $2)
+ON CONFLICT (public_key) DO UPDATE SET next_nonce = EXCLUDED.next_nonce
+
+--name: create-lock-audit
+-- Records a system lock change
+-- $1: scope
+-- $2: locked
+-- $3: reason
+-- $4: set_by
+-- $5: expires_at
+INSERT INTO system_lock_audit(scope, locked, reason, set_by, expires_at) VALUES($1, $2, $3, $4, $5)
+
+--name: get-latest-lock-audits
+-- Gets the last change of every system lock scope
+SELECT DISTINCT ON (scope) scope, locked, reason, set_by, expires_at, created_at
+FROM system_lock_audit
+ORDER BY scope, id DESC
+
+--name: create-webhook
+-- Registers a callback url for a tracking id
+-- $1: tracking_id
+-- $2: callback_url
+-- $3: client_id
+INSERT INTO webhook(tracking_id, callback_url, client_id) VALUES($1, $2, $3)
+
+--name: get-webhook
+-- Gets the callback url of a tracking id and the secret of the client which registered it
+-- $1: tracking_id
+SELECT webhook.id, webhook.callback_url, api_client.webhook_secret, api_client.webhook_secret_data_key, api_client.webhook_secret_key_version FROM webhook
+INNER JOIN api_client ON webhook.client_id = api_client.id
+WHERE webhook.tracking_id=$1 AND api_client.webhook_secret IS NOT NULL
+
+--name: create-webhook-delivery
+-- Records a webhook delivery attempt
+-- $1: webhook_id
+-- $2: event
+-- $3: tx_hash
+-- $4: attempt
+-- $5: status_code
+-- $6: error
+INSERT INTO webhook_delivery(webhook_id, "event", tx_hash, attempt, status_code, error) VALUES($1, $2, $3, $4, $5, $6)
+
+--name: get-webhook-deliveries-by-tracking-id
+-- Gets every webhook delivery attempt of a tracking id in the order they were made
+-- $1: tracking_id
+SELECT webhook_delivery."event", webhook_delivery.tx_hash, webhook_delivery.attempt, webhook_delivery.status_code, webhook_delivery.error, webhook_delivery.created_at
+FROM webhook_delivery
+INNER JOIN webhook ON webhook_delivery.webhook_id = webhook.id
+WHERE webhook.tracking_id=$1
+ORDER BY webhook_delivery.id
+
+--name: get-transfer-policy
+-- Gets the most specific active transfer policy for an account and voucher
+-- $1: public_key
+-- $2: voucher_address
+SELECT id, public_key, voucher_address, max_per_transfer::TEXT, max_per_day::TEXT, max_transfers_per_hour FROM transfer_policy
+WHERE active = true
+AND (public_key = $1 OR public_key IS NULL)
+AND (voucher_address = $2 OR voucher_address IS NULL)
+ORDER BY public_key IS NULL, voucher_address IS NULL
+LIMIT 1
+
+--name: get-transfer-usage
+-- Gets the value transferred or approved by an account over the last day and its transfer count over the last hour
+-- Replacements re-sign an already counted transfer and are excluded
+-- A transfer is not counted once any of its attempts (the otx and its replacements at the same nonce) reverted or failed dispatch
+-- $1: from
+-- $2: voucher_address, empty for every voucher
+SELECT
+COALESCE(SUM(transfer_value), 0)::TEXT AS day_value,
+COUNT(*) FILTER (WHERE "type" = 'TRANSFER_VOUCHER' AND created_at > CURRENT_TIMESTAMP - INTERVAL '1 hour') AS hour_count
+FROM otx_sign
+WHERE "from" = $1
+AND "type" IN ('TRANSFER_VOUCHER', 'TRANSFER_AUTHORIZATION')
+AND replaces IS NULL
+AND ($2 = '' OR voucher_address = $2)
+AND created_at > CURRENT_TIMESTAMP - INTERVAL '1 day'
+AND NOT EXISTS (
+    SELECT 1 FROM otx_sign attempt
+    INNER JOIN otx_dispatch ON attempt.id = otx_dispatch.otx_id
+    WHERE attempt.tracking_id = otx_sign.tracking_id
+    AND attempt.nonce = otx_sign.nonce
+    AND otx_dispatch.status IN ('REVERTED', 'FAIL_NO_GAS', 'FAIL_LOW_NONCE', 'FAIL_UNKNOWN_RPC_ERROR')
+)
+
+--name: add-voucher
+-- Manually allowlists a voucher
+-- $1: voucher_address
+-- $2: reason
+-- $3: added_by
+INSERT INTO voucher_allowlist(voucher_address, reason, added_by) VALUES($1, $2, $3)
+ON CONFLICT (voucher_address) DO NOTHING
+
+--name: remove-voucher
+-- Removes a manually allowlisted voucher
+-- $1: voucher_address
+DELETE FROM voucher_allowlist WHERE voucher_address=$1
+
+--name: get-vouchers
+-- Gets every manually allowlisted voucher
+SELECT voucher_address, reason, added_by, created_at FROM voucher_allowlist ORDER BY id